@@ -0,0 +1,63 @@
+package zapsentry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultMaxAttachmentSize = 20 << 20
+
+// ErrAttachmentTooLarge is passed to Configuration.ErrorHandler for attachments
+// exceeding Configuration.MaxAttachmentSize, which are skipped.
+var ErrAttachmentTooLarge = errors.New("sentry attachment too large")
+
+// fieldAttachments returns the attachments added to the entry with Attachment and AttachmentFunc.
+func (c *core) fieldAttachments(fs []zapcore.Field) ([]*sentry.Attachment, []error) {
+	var (
+		attachments []*sentry.Attachment
+		errs        []error
+	)
+
+	for _, f := range fs {
+		field, ok := f.Interface.(attachmentField)
+		if !ok || f.Type != zapcore.SkipType {
+			continue
+		}
+
+		data, err := field.Data()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("attachment %q: %w", field.Filename, err))
+			continue
+		}
+		if len(data) > c.cfg.MaxAttachmentSize {
+			errs = append(errs, fmt.Errorf("%w: %q has %d bytes, more than %d", ErrAttachmentTooLarge, field.Filename, len(data), c.cfg.MaxAttachmentSize))
+			continue
+		}
+
+		attachments = append(attachments, &sentry.Attachment{
+			Filename:    field.Filename,
+			ContentType: field.ContentType,
+			Payload:     data,
+		})
+	}
+
+	return attachments, errs
+}
+
+// limitedBuffer fails writes exceeding the limit with ErrAttachmentTooLarge.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrAttachmentTooLarge, b.limit)
+	}
+
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,55 @@
+package zapsentry_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func TestAttachment(t *testing.T) {
+	var handled []error
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		EnableBreadcrumbs: true,
+		BreadcrumbLevel:   zapcore.InfoLevel,
+		MaxAttachmentSize: 8,
+		ErrorHandler:      func(err error) { handled = append(handled, err) },
+	})
+
+	calls := 0
+	lazy := zapsentry.AttachmentFunc("lazy.txt", "text/plain", func() ([]byte, error) {
+		calls++
+		return []byte("lazy"), nil
+	})
+
+	logger.Info("breadcrumb only", lazy)
+	if calls != 0 {
+		t.Errorf("expected lazy attachment not to be called for breadcrumbs")
+	}
+
+	logger.With(zapsentry.Attachment("with.txt", "text/plain", []byte("with"))).Error("failed",
+		zapsentry.Attachment("payload.json", "application/json", []byte(`{"a":1}`)),
+		zapsentry.Attachment("large.bin", "application/octet-stream", make([]byte, 9)),
+		zapsentry.AttachmentFunc("broken.txt", "text/plain", func() ([]byte, error) { return nil, errors.New("unavailable") }),
+		lazy,
+	)
+
+	attachments := transport.LastEvent().Attachments
+	if len(attachments) != 2 {
+		t.Fatalf("expected 2 attachments, got %d", len(attachments))
+	}
+	if a := attachments[0]; a.Filename != "payload.json" || a.ContentType != "application/json" || string(a.Payload) != `{"a":1}` {
+		t.Errorf("unexpected attachment %+v", a)
+	}
+	if a := attachments[1]; a.Filename != "lazy.txt" || string(a.Payload) != "lazy" || calls != 1 {
+		t.Errorf("unexpected attachment %+v after %d calls", a, calls)
+	}
+
+	if len(handled) != 2 || !errors.Is(handled[0], zapsentry.ErrAttachmentTooLarge) {
+		t.Errorf("expected oversized and failed attachments to be reported, got %v", handled)
+	}
+}
@@ -7,20 +7,93 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// WithErrorsPolicy defines how errors attached with zap.Logger.With are reported.
+type WithErrorsPolicy int
+
+const (
+	// WithErrorsAsExceptions reports them as exceptions of every event, along with errors of the entry.
+	WithErrorsAsExceptions WithErrorsPolicy = iota
+	// WithErrorsAsContext never reports them as exceptions.
+	WithErrorsAsContext
+	// WithErrorsAsFallback reports them as exceptions only if the entry has no errors of its own.
+	WithErrorsAsFallback
+)
+
+// EventTitle defines how the message and errors of an entry make the title of the Sentry issue,
+// which is based on the primary (last) exception.
+type EventTitle int
+
+const (
+	// EventTitleException keeps the primary error as the primary exception.
+	EventTitleException EventTitle = iota
+	// EventTitleMessage adds a synthetic primary exception typed by the message,
+	// the errors being its causes.
+	EventTitleMessage
+	// EventTitleMessageAndError is like EventTitleMessage, but the value of
+	// the synthetic exception is the text of the primary error.
+	EventTitleMessageAndError
+)
+
 // Configuration is a minimal set of parameters for Sentry integration.
 type Configuration struct {
 	// Tags are passed as is to the corresponding sentry.Event field.
 	Tags map[string]string
 
-	// LoggerNameKey is the key for zap logger name.
+	// TagPolicy defines what happens to tags exceeding Sentry limits.
+	// Every tag changed to fit Sentry is reported with ErrInvalidTag.
+	TagPolicy TagPolicy
+
+	// LoggerNameKey is the key for zap logger name, which is always reported as sentry.Event.Logger.
 	// If not empty, the name is added to the rest of zapcore.Field(s),
 	// so that be careful with key duplicates.
 	// Leave LoggerNameKey empty to disable the feature.
 	LoggerNameKey string
 
+	// LoggerNameTagKey is the key of tags with zap logger name.
+	// If not empty, the name is added under the key, and every its dotted prefix
+	// under the key suffixed with the prefix length, e.g. "svc.db.pool" results in
+	// "logger": "svc.db.pool", "logger.1": "svc" and "logger.2": "svc.db" for the "logger" key.
+	// Leave LoggerNameTagKey empty to disable the feature.
+	LoggerNameTagKey string
+
 	// DisableStacktrace disables adding stacktrace to sentry.Event, if set.
 	DisableStacktrace bool
 
+	// DisableThreadDump disables adding stacks of all goroutines as sentry.Thread(s) to events
+	// of levels above Error, if set. It has no effect if DisableStacktrace is set.
+	DisableThreadDump bool
+
+	// RequestHeaderDenylist are the headers of requests passed with Request not to send to Sentry,
+	// matched case-insensitively. The Cookie header also controls sentry.Request.Cookies,
+	// which are sent along with the peer address only if sentry.ClientOptions.SendDefaultPII is set.
+	// Leave it nil to use DefaultRequestHeaderDenylist.
+	RequestHeaderDenylist []string
+
+	// MaxRequestBodySize is the maximum number of bytes of request bodies to send to Sentry.
+	// The body is the one passed with RequestBody, or else the one returned by http.Request.GetBody,
+	// which is set for client requests only.
+	// Leave it zero to disable sending bodies of requests passed with Request.
+	MaxRequestBodySize int
+
+	// AttachProfiles enables attaching pprof goroutine and heap profiles to events of Panic and Fatal entries.
+	// Profiles exceeding MaxAttachmentSize are skipped with ErrAttachmentTooLarge.
+	AttachProfiles bool
+
+	// ProfilesInterval is the minimal interval between attaching profiles by any core of the process.
+	// Leave it zero or set to negative for a reasonable default value.
+	// The field is ignored, if AttachProfiles is not set.
+	ProfilesInterval time.Duration
+
+	// MaxAttachmentSize is the maximum size of a single attachment in bytes.
+	// Leave it zero or set to negative for a reasonable default value.
+	MaxAttachmentSize int
+
+	// DisableRuntimeContext disables adding the "runtime" context with Go runtime and memory stats,
+	// the "build" context with VCS information and modules to sentry.Event, if set.
+	// Modules are added only if ClientOptions.Integrations removes the sentry-go Modules integration,
+	// which adds them otherwise.
+	DisableRuntimeContext bool
+
 	// Level is the minimal level of sentry.Event(s).
 	Level zapcore.LevelEnabler
 
@@ -46,6 +119,64 @@ type Configuration struct {
 	// See sentry.Hub docs for more detail.
 	Hub *sentry.Hub
 
+	// SeverityMapper converts zap levels to sentry.Level(s).
+	// Use it e.g. to report DPanic as an error in production or to map custom levels.
+	// Leave it nil to use DefaultSeverityMapper.
+	SeverityMapper SeverityMapper
+
+	// LevelNames are names of custom zap levels, i.e. outside the range from Debug to Fatal.
+	// Breadcrumbs of custom levels carry the name under the "zap_level" data key,
+	// which doesn't clash with the common "level" field.
+	// Levels missing from the map are named by zapcore.Level.String.
+	LevelNames map[zapcore.Level]string
+
+	// ExpvarName is the name to publish the core Stats under with expvar.
+	// A core replaces the Stats published under the name by another one, e.g. closed on restart;
+	// NewCore fails if the name is taken by a variable published otherwise.
+	// Leave ExpvarName empty to disable the feature.
+	ExpvarName string
+
+	// ErrorHandler is called on failures of reporting to Sentry: dropped events (see ErrEventDropped),
+	// failed factory calls, flush timeouts, panics while encoding fields and skipped attachments.
+	// Leave it nil to write errors to stderr, except ErrEventDropped, unless ReturnErrors is set.
+	ErrorHandler func(error)
+
+	// ReturnErrors makes Write return the errors passed to ErrorHandler,
+	// so that zap reports them to its ErrorOutput.
+	ReturnErrors bool
+
+	// StacktraceExtractor extracts stack traces carried by errors of third-party libraries.
+	// It is tried for each error in the chain before the built-in extractors,
+	// see CombineStacktraceExtractors to register several ones.
+	StacktraceExtractor StacktraceExtractor
+
+	// WithErrors controls whether errors attached with zap.Logger.With are reported as exceptions.
+	// They are always added to the rest of zapcore.Field(s) and contribute tags and contexts.
+	WithErrors WithErrorsPolicy
+
+	// PrimaryErrorKey is the key of the error field to report as the primary exception,
+	// which Sentry uses for the issue title.
+	// Leave it empty for the most recently attached error, the ones of the entry being after the ones of With.
+	PrimaryErrorKey string
+
+	// EventTitle defines how the message and errors of entries are combined in issue titles.
+	// It has no effect on entries without errors.
+	EventTitle EventTitle
+
+	// VerboseExceptionValues formats exception values with "%+v" for errors implementing fmt.Formatter,
+	// e.g. to include details added by errors libraries.
+	VerboseExceptionValues bool
+
+	// MaxErrorDepth is the maximum number of errors reported from a single error chain,
+	// including the errors joined with errors.Join and their chains.
+	// Longer chains end with a synthetic "ChainTruncated" exception.
+	// Leave it zero or set to negative for a reasonable default value.
+	MaxErrorDepth int
+
+	// ExceptionTypeNamer names the types of sentry.Exception(s).
+	// Leave it nil to use DefaultExceptionTypeNamer without sentinel errors.
+	ExceptionTypeNamer ExceptionTypeNamer
+
 	// FrameMatcher allows to ignore some frames of the stack trace.
 	// this is particularly useful when you want to ignore for instances frames from convenience wrappers
 	FrameMatcher FrameMatcher
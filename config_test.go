@@ -9,6 +9,7 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
 )
 
 func TestLevelEnabler(t *testing.T) {
@@ -16,10 +17,8 @@ func TestLevelEnabler(t *testing.T) {
 	core, recordedLogs := observer.New(lvl)
 	logger := zap.New(core)
 
-	var recordedSentryEvent *sentry.Event
-	sentryClient := mockSentryClient(func(event *sentry.Event) {
-		recordedSentryEvent = event
-	})
+	transport := zapsentrytest.NewTransport()
+	sentryClient := zapsentrytest.NewClient(transport, sentry.ClientOptions{})
 
 	core, err := zapsentry.NewCore(
 		zapsentry.Configuration{Level: lvl},
@@ -31,18 +30,18 @@ func TestLevelEnabler(t *testing.T) {
 	newLogger := zapsentry.AttachCoreToLogger(core, logger)
 
 	newLogger.Error("foo")
-	if recordedLogs.Len() > 0 || recordedSentryEvent != nil {
+	if recordedLogs.Len() > 0 || transport.LastEvent() != nil {
 		t.Errorf("expected no logs before level change")
 		t.Logf("logs=%v", recordedLogs.All())
-		t.Logf("events=%v", recordedSentryEvent)
+		t.Logf("events=%v", transport.Events())
 	}
 
 	lvl.SetLevel(zap.ErrorLevel)
 	newLogger.Error("bar")
-	if recordedLogs.Len() != 1 || recordedSentryEvent == nil {
+	if recordedLogs.Len() != 1 || transport.LastEvent() == nil {
 		t.Errorf("expected exactly one log after level change")
 		t.Logf("logs=%v", recordedLogs.All())
-		t.Logf("events=%v", recordedSentryEvent)
+		t.Logf("events=%v", transport.Events())
 	}
 }
 
@@ -52,7 +51,7 @@ func TestBreadcrumbLevelEnabler(t *testing.T) {
 
 	_, err := zapsentry.NewCore(
 		zapsentry.Configuration{Level: corelvl, BreadcrumbLevel: breadlvl, EnableBreadcrumbs: true},
-		zapsentry.NewSentryClientFromClient(mockSentryClient(func(event *sentry.Event) {})),
+		zapsentry.NewSentryClientFromClient(zapsentrytest.NewClient(zapsentrytest.NewTransport(), sentry.ClientOptions{})),
 	)
 	if !errors.Is(err, zapsentry.ErrInvalidBreadcrumbLevel) {
 		t.Errorf("expected ErrInvalidBreadcrumbLevel, got %v", err)
@@ -0,0 +1,65 @@
+package zapsentry
+
+import (
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type contextDataField struct {
+	Name string
+	Data map[string]interface{}
+}
+
+type contextObjectField struct {
+	Name   string
+	Object zapcore.ObjectMarshaler
+}
+
+// ContextData adds the data to the named context of sentry.Event(s) instead of "Extra",
+// so that Sentry shows it as a separate section.
+// Data of the same context passed with With and to the entry is merged, the later keys taking precedence.
+func ContextData(name string, data map[string]interface{}) zap.Field {
+	return zap.Field{Key: name, Type: zapcore.SkipType, Interface: contextDataField{name, data}}
+}
+
+// ContextObject is like ContextData, but the data is encoded from the object.
+func ContextObject(name string, object zapcore.ObjectMarshaler) zap.Field {
+	return zap.Field{Key: name, Type: zapcore.SkipType, Interface: contextObjectField{name, object}}
+}
+
+// marshalContext encodes the object the way zap.Object does, recovering from panics of custom marshalers.
+func marshalContext(name string, object zapcore.ObjectMarshaler) (data map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("encode context %q: panic: %v", name, r)
+		}
+	}()
+
+	enc := zapcore.NewMapObjectEncoder()
+	if err := object.MarshalLogObject(enc); err != nil {
+		return enc.Fields, fmt.Errorf("encode context %q: %w", name, err)
+	}
+
+	return enc.Fields, nil
+}
+
+// addContexts adds the contexts of ContextData and ContextObject fields to the event,
+// merging them with the contexts already there.
+func (c *core) addContexts(event *sentry.Event) {
+	for name, data := range c.contexts {
+		existing := event.Contexts[name]
+
+		// don't let event processors modify contexts shared with the core.
+		merged := make(sentry.Context, len(existing)+len(data))
+		for k, v := range existing {
+			merged[k] = v
+		}
+		for k, v := range data {
+			merged[k] = v
+		}
+		event.Contexts[name] = merged
+	}
+}
@@ -0,0 +1,66 @@
+package zapsentry_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+type payment struct {
+	id     string
+	amount int
+}
+
+func (p payment) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", p.id)
+	enc.AddInt("amount", p.amount)
+	return nil
+}
+
+type brokenObject struct{}
+
+func (brokenObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("partial", "yes")
+	return errors.New("broken")
+}
+
+func TestContextData(t *testing.T) {
+	var handled []error
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:        zapcore.ErrorLevel,
+		ErrorHandler: func(err error) { handled = append(handled, err) },
+	})
+
+	jobLogger := logger.With(zapsentry.ContextData("job", map[string]interface{}{"name": "sync", "attempt": 1}))
+
+	jobLogger.Error("failed",
+		zapsentry.ContextData("job", map[string]interface{}{"attempt": 2}),
+		zapsentry.ContextObject("payment", payment{id: "p1", amount: 10}),
+	)
+
+	event := transport.LastEvent()
+	if want := map[string]interface{}{"name": "sync", "attempt": 2}; !reflect.DeepEqual(map[string]interface{}(event.Contexts["job"]), want) {
+		t.Errorf("expected job context %v, got %v", want, event.Contexts["job"])
+	}
+	if want := map[string]interface{}{"id": "p1", "amount": 10}; !reflect.DeepEqual(map[string]interface{}(event.Contexts["payment"]), want) {
+		t.Errorf("expected payment context %v, got %v", want, event.Contexts["payment"])
+	}
+	if _, ok := event.Contexts["Extra"]["job"]; ok {
+		t.Errorf("expected job not to be in Extra")
+	}
+
+	jobLogger.Error("failed again", zapsentry.ContextObject("broken", brokenObject{}))
+
+	event = transport.LastEvent()
+	if want := map[string]interface{}{"name": "sync", "attempt": 1}; !reflect.DeepEqual(map[string]interface{}(event.Contexts["job"]), want) {
+		t.Errorf("expected job context of With to be unchanged, got %v", event.Contexts["job"])
+	}
+	if event.Contexts["broken"]["partial"] != "yes" || len(handled) != 1 {
+		t.Errorf("expected partial context and an encoding error, got %v and %v", event.Contexts["broken"], handled)
+	}
+}
@@ -1,8 +1,14 @@
 package zapsentry
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"os"
 	"reflect"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -12,19 +18,61 @@ import (
 
 const (
 	defaultMaxBreadcrumbs = 100
-	maxErrorDepth         = 10
+	defaultMaxErrorDepth  = 10
 
 	zapSentryScopeKey = "_zapsentry_scope_"
+
+	breadcrumbLevelKey = "zap_level"
+
+	chainTruncatedType = "ChainTruncated"
+
+	mechanismType        = "zap"
+	mechanismFieldKey    = "field"
+	mechanismSourceCause = "cause"
+	mechanismTitleKey    = "message"
 )
 
 var (
 	ErrInvalidBreadcrumbLevel = errors.New("breadcrumb level must be lower than or equal to error level")
+	ErrFlushTimeout           = errors.New("sentry flush timed out")
+	// ErrEventDropped is passed to Configuration.ErrorHandler when sentry.Client rejects an event,
+	// e.g. because of sampling, an event processor or BeforeSend.
+	ErrEventDropped = errors.New("sentry event dropped")
 )
 
 type ClientGetter interface {
 	GetClient() *sentry.Client
 }
 
+// ContextSyncer is implemented by the core to flush buffered events
+// until the context is done rather than for Configuration.FlushTimeout.
+type ContextSyncer interface {
+	SyncContext(ctx context.Context) error
+}
+
+// Closer is implemented by the core to flush buffered events and release its resources.
+// The sentry.Client is closed only if the core owns it, see OwnedSentryClientFactory.
+// Writes to a closed core, including its clones made by With, are discarded.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// FlushTimeoutError is returned by Sync when not all the events were delivered in time.
+// It matches ErrFlushTimeout with errors.Is.
+type FlushTimeoutError struct {
+	// Pending is the number of events captured since the last successful flush.
+	// Some of them might have been delivered nevertheless.
+	Pending int64
+}
+
+func (e *FlushTimeoutError) Error() string {
+	return fmt.Sprintf("%v with %d events pending", ErrFlushTimeout, e.Pending)
+}
+
+func (e *FlushTimeoutError) Is(target error) bool {
+	return target == ErrFlushTimeout
+}
+
 func NewScopeFromScope(scope *sentry.Scope) zapcore.Field {
 	f := zap.Skip()
 	f.Interface = scope
@@ -37,20 +85,57 @@ func NewScope() zapcore.Field {
 	return NewScopeFromScope(sentry.NewScope())
 }
 
-func NewCore(cfg Configuration, factory SentryClientFactory) (zapcore.Core, error) {
+// NewCore creates the core with the client returned by the factory.
+// The core owns the client only if the factory is an OwnedSentryClientFactory.
+func NewCore[F ClientFactory](cfg Configuration, factory F) (zapcore.Core, error) {
+	if cfg.ErrorHandler == nil && !cfg.ReturnErrors {
+		cfg.ErrorHandler = defaultErrorHandler
+	}
+
 	client, err := factory()
 	if err != nil {
+		if cfg.ErrorHandler != nil {
+			cfg.ErrorHandler(fmt.Errorf("create sentry client: %w", err))
+		}
 		return zapcore.NewNopCore(), err
 	}
 
-	if cfg.EnableBreadcrumbs && zapcore.LevelOf(cfg.BreadcrumbLevel) > zapcore.LevelOf(cfg.Level) {
-		return zapcore.NewNopCore(), ErrInvalidBreadcrumbLevel
+	_, ownsClient := any(factory).(OwnedSentryClientFactory)
+
+	if cfg.EnableBreadcrumbs {
+		breadcrumbLevel, _ := minEnabledLevel(cfg.BreadcrumbLevel)
+		if level, ok := minEnabledLevel(cfg.Level); ok && breadcrumbLevel > level {
+			if ownsClient {
+				client.Close()
+			}
+			return zapcore.NewNopCore(), ErrInvalidBreadcrumbLevel
+		}
+	}
+
+	if cfg.ExceptionTypeNamer == nil {
+		cfg.ExceptionTypeNamer = DefaultExceptionTypeNamer{}
+	}
+
+	if cfg.SeverityMapper == nil {
+		cfg.SeverityMapper = DefaultSeverityMapper
+	}
+
+	if cfg.MaxErrorDepth <= 0 {
+		cfg.MaxErrorDepth = defaultMaxErrorDepth
 	}
 
 	if cfg.MaxBreadcrumbs <= 0 {
 		cfg.MaxBreadcrumbs = defaultMaxBreadcrumbs
 	}
 
+	if cfg.MaxAttachmentSize <= 0 {
+		cfg.MaxAttachmentSize = defaultMaxAttachmentSize
+	}
+
+	if cfg.ProfilesInterval <= 0 {
+		cfg.ProfilesInterval = defaultProfilesInterval
+	}
+
 	// copy default values to prevent accidental modification.
 	matchers := make(FrameMatchers, len(defaultFrameMatchers), len(defaultFrameMatchers)+1)
 	copy(matchers, defaultFrameMatchers)
@@ -61,6 +146,13 @@ func NewCore(cfg Configuration, factory SentryClientFactory) (zapcore.Core, erro
 		cfg.FrameMatcher = matchers
 	}
 
+	// custom extractors take precedence over the default ones.
+	extractors := make(StacktraceExtractors, 0, len(defaultStacktraceExtractors)+1)
+	if cfg.StacktraceExtractor != nil {
+		extractors = append(extractors, cfg.StacktraceExtractor)
+	}
+	cfg.StacktraceExtractor = append(extractors, defaultStacktraceExtractors...)
+
 	var flushTimeout = time.Second * 5
 	if cfg.FlushTimeout > 0 {
 		flushTimeout = cfg.FlushTimeout
@@ -75,14 +167,27 @@ func NewCore(cfg Configuration, factory SentryClientFactory) (zapcore.Core, erro
 			enableBreadcrumbs: cfg.EnableBreadcrumbs,
 		},
 		flushTimeout: flushTimeout,
+		state:        &state{ownsClient: ownsClient, modulesIntegration: hasModulesIntegration(client), stats: newStats()},
 		fields:       make(map[string]interface{}),
 	}
 
+	if cfg.ExpvarName != "" {
+		if err := core.state.stats.publish(cfg.ExpvarName); err != nil {
+			if ownsClient {
+				client.Close()
+			}
+			return zapcore.NewNopCore(), err
+		}
+	}
+
 	return &core, nil
 }
 
 func (c *core) With(fs []zapcore.Field) zapcore.Core {
-	return c.with(fs)
+	clone, err := c.with(fs)
+	_ = c.handleError(err)
+
+	return clone
 }
 
 func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
@@ -96,71 +201,150 @@ func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.Check
 }
 
 func (c *core) Write(ent zapcore.Entry, fs []zapcore.Field) error {
-	clone := c.with(c.addSpecialFields(ent, fs))
+	if c.state.closed.Load() {
+		c.state.stats.addDiscarded()
+		return nil
+	}
+
+	clone, err := c.with(c.addSpecialFields(ent, fs))
+	errs := []error{err}
 
 	if c.cfg.EnableBreadcrumbs && c.cfg.BreadcrumbLevel.Enabled(ent.Level) {
 		breadcrumb := sentry.Breadcrumb{
 			Message:   ent.Message,
 			Data:      clone.fields,
-			Level:     sentrySeverity(ent.Level),
+			Level:     c.cfg.SeverityMapper.Severity(ent.Level),
 			Timestamp: ent.Time,
 		}
 
+		// Sentry knows nothing about custom levels, so keep their names.
+		if isCustomLevel(ent.Level) {
+			data := make(map[string]interface{}, len(clone.fields)+1)
+			for k, v := range clone.fields {
+				data[k] = v
+			}
+			data[breadcrumbLevelKey] = c.levelName(ent.Level)
+			breadcrumb.Data = data
+		}
+
 		c.scope().AddBreadcrumb(&breadcrumb, c.cfg.MaxBreadcrumbs)
+		c.state.stats.add(c.levelName(ent.Level), ent.LoggerName, Counts{Breadcrumbs: 1})
 	}
 
 	if c.cfg.Level.Enabled(ent.Level) {
-		tagsCount := len(c.cfg.Tags)
-		for _, f := range fs {
-			if f.Type == zapcore.SkipType {
-				if _, ok := f.Interface.(tagField); ok {
-					tagsCount++
-				}
-			}
-		}
+		buildStart := time.Now()
 
 		var hint *sentry.EventHint
+		if ctx := clone.context(); ctx != nil {
+			hint = &sentry.EventHint{Context: ctx, Request: clone.request}
+		}
 
 		event := sentry.NewEvent()
 		event.Message = ent.Message
 		event.Timestamp = ent.Time
-		event.Level = sentrySeverity(ent.Level)
+		event.Level = c.cfg.SeverityMapper.Severity(ent.Level)
+		event.Logger = ent.LoggerName
 		event.Contexts["Extra"] = clone.fields
-		event.Tags = make(map[string]string, tagsCount)
+		if clone.request != nil {
+			request, err := clone.newRequest(clone.request)
+			event.Request = request
+			errs = append(errs, err)
+		}
+		c.addRuntimeInfo(event)
+		clone.addContexts(event)
+		event.Tags = make(map[string]string, len(c.cfg.Tags)+len(clone.tags)+len(clone.contextTags))
 		for k, v := range c.cfg.Tags {
 			event.Tags[k] = v
 		}
-		for _, f := range fs {
-			if f.Type == zapcore.SkipType {
-				switch t := f.Interface.(type) {
-				case tagField:
-					event.Tags[t.Key] = t.Value
-				case ctxField:
-					hint = &sentry.EventHint{Context: t.Value}
-				}
-			}
-		}
-		event.Exception = clone.createExceptions()
+		c.addLoggerNameTags(event, ent.LoggerName)
+		exceptionErrs := clone.exceptionErrors(len(c.errs))
+		clone.applyErrorProviders(event, exceptionErrs)
+		clone.addErrorDetails(event)
+		clone.addTags(event)
+		errs = append(errs, c.sanitizeTags(event)...)
+		// Panic and Fatal entries crash the program, so their errors aren't handled.
+		event.Exception = clone.createExceptions(exceptionErrs, ent.Level < zapcore.PanicLevel)
+		event.Exception = c.addTitleException(event.Exception, ent.Message)
+
+		// Panic and Fatal entries crash the current goroutine.
+		crashed := ent.Level >= zapcore.PanicLevel
 
 		if event.Exception == nil && !c.cfg.DisableStacktrace && c.client.Options().AttachStacktrace {
 			stacktrace := sentry.NewStacktrace()
 			if stacktrace != nil {
 				stacktrace.Frames = c.filterFrames(stacktrace.Frames)
-				event.Threads = []sentry.Thread{{Stacktrace: stacktrace, Current: true}}
+				event.Threads = []sentry.Thread{{Stacktrace: stacktrace, Current: true, Crashed: crashed}}
 			}
 		}
 
-		_ = c.client.CaptureEvent(event, hint, c.scope())
+		// We may be crashing the program, so should report what every goroutine was doing.
+		if ent.Level > zapcore.ErrorLevel && !c.cfg.DisableStacktrace && !c.cfg.DisableThreadDump {
+			event.Threads = c.threadDump(crashed)
+		}
+
+		attachments, attachmentErrs := c.fieldAttachments(fs)
+		event.Attachments = attachments
+		errs = append(errs, attachmentErrs...)
+
+		if ent.Level >= zapcore.PanicLevel {
+			profiles, profileErrs := c.profileAttachments()
+			event.Attachments = append(event.Attachments, profiles...)
+			errs = append(errs, profileErrs...)
+		}
+
+		c.state.stats.addBuildTime(time.Since(buildStart))
+
+		if c.client.CaptureEvent(event, hint, c.scope()) != nil {
+			c.state.pending.Add(1)
+			c.state.stats.add(c.levelName(ent.Level), ent.LoggerName, Counts{Events: 1})
+		} else {
+			c.state.stats.add(c.levelName(ent.Level), ent.LoggerName, Counts{Dropped: 1})
+			errs = append(errs, fmt.Errorf("%w: %q", ErrEventDropped, ent.Message))
+		}
 	}
 
 	// We may be crashing the program, so should flush any buffered events.
 	if ent.Level > zapcore.ErrorLevel {
-		return c.Sync()
+		errs = append(errs, c.flushed(c.client.Flush(c.flushTimeout)))
+	}
+
+	return c.handleError(errors.Join(errs...))
+}
+
+// handleError passes every joined error to Configuration.ErrorHandler
+// and returns err if Configuration.ReturnErrors is set.
+func (c *core) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if c.cfg.ErrorHandler != nil {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				c.cfg.ErrorHandler(e)
+			}
+		} else {
+			c.cfg.ErrorHandler(err)
+		}
+	}
+
+	if c.cfg.ReturnErrors {
+		return err
 	}
 
 	return nil
 }
 
+// defaultErrorHandler writes errors to stderr the way zap writes to its ErrorOutput.
+// Dropped events are skipped, as sampling and filtering are usually deliberate.
+func defaultErrorHandler(err error) {
+	if errors.Is(err, ErrEventDropped) {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%v zapsentry error: %v\n", time.Now(), err)
+}
+
 func (c *core) addSpecialFields(ent zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
 	if c.cfg.LoggerNameKey != "" && ent.LoggerName != "" {
 		fs = append(fs, zap.String(c.cfg.LoggerNameKey, ent.LoggerName))
@@ -169,18 +353,77 @@ func (c *core) addSpecialFields(ent zapcore.Entry, fs []zapcore.Field) []zapcore
 	return fs
 }
 
-func (c *core) createExceptions() []sentry.Exception {
-	errorsCount := len(c.errs)
+// addLoggerNameTags adds tags with the logger name and its dotted prefixes, see Configuration.LoggerNameTagKey.
+func (c *core) addLoggerNameTags(event *sentry.Event, name string) {
+	if c.cfg.LoggerNameTagKey == "" || name == "" {
+		return
+	}
+
+	event.Tags[c.cfg.LoggerNameTagKey] = name
+
+	depth := 0
+	for i, r := range name {
+		if r == '.' {
+			depth++
+			event.Tags[c.cfg.LoggerNameTagKey+"."+strconv.Itoa(depth)] = name[:i]
+		}
+	}
+}
+
+func (c *core) levelName(lvl zapcore.Level) string {
+	if name, ok := c.cfg.LevelNames[lvl]; ok {
+		return name
+	}
+
+	return lvl.String()
+}
+
+// exceptionErrors selects the errors reported as exceptions according to Configuration.WithErrors,
+// given the number of errors attached with With, and makes the primary error the last one.
+func (c *core) exceptionErrors(withCount int) []errorField {
+	var errs []errorField
+
+	switch entryErrs := c.errs[withCount:]; c.cfg.WithErrors {
+	case WithErrorsAsContext:
+		errs = entryErrs
+	case WithErrorsAsFallback:
+		if len(entryErrs) > 0 {
+			errs = entryErrs
+		} else {
+			errs = c.errs
+		}
+	default:
+		errs = c.errs
+	}
+
+	if c.cfg.PrimaryErrorKey == "" {
+		return errs
+	}
+
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i].key == c.cfg.PrimaryErrorKey {
+			reordered := make([]errorField, 0, len(errs))
+			reordered = append(reordered, errs[:i]...)
+			reordered = append(reordered, errs[i+1:]...)
+			return append(reordered, errs[i])
+		}
+	}
+
+	return errs
+}
+
+func (c *core) createExceptions(errs []errorField, handled bool) []sentry.Exception {
+	errorsCount := len(errs)
 
 	if errorsCount == 0 {
 		return nil
 	}
 
-	processedErrors := make(map[string]struct{}, errorsCount)
+	processedErrors := make(map[error]struct{}, errorsCount)
 	exceptions := make([]sentry.Exception, 0, errorsCount)
 
 	for i := errorsCount - 1; i >= 0; i-- {
-		exceptions = c.addExceptionsFromError(exceptions, processedErrors, c.errs[i])
+		exceptions = c.addExceptionsFromError(exceptions, processedErrors, errs[i], handled)
 	}
 
 	if !c.cfg.DisableStacktrace && exceptions[0].Stacktrace == nil {
@@ -200,26 +443,53 @@ func (c *core) createExceptions() []sentry.Exception {
 	return exceptions
 }
 
-func getTypeOf(err error) string {
-	return err.Error() + reflect.TypeOf(err).String()
+// isComparable reports whether the error can be used as a map key identifying the error instance.
+func isComparable(err error) bool {
+	return reflect.ValueOf(err).Comparable()
 }
 
 func (c *core) addExceptionsFromError(
 	exceptions []sentry.Exception,
-	processedErrors map[string]struct{},
-	err error,
+	processedErrors map[error]struct{},
+	field errorField,
+	handled bool,
 ) []sentry.Exception {
-	for i := 0; i < maxErrorDepth && err != nil; i++ {
-		if _, ok := processedErrors[getTypeOf(err)]; ok {
-			return exceptions
+	handled = handled && !field.recovered
+
+	first := len(exceptions)
+
+	for _, link := range c.errorChain(field.err, processedErrors) {
+		// exception IDs follow the order of the chain.
+		var parentID *int
+		if link.parent >= 0 {
+			id := first + link.parent
+			parentID = &id
+		}
+
+		mechanism := newMechanism(field.key, handled, len(exceptions), parentID)
+		if link.joinIndex >= 0 {
+			mechanism.Source = fmt.Sprintf("errors[%d]", link.joinIndex)
 		}
 
-		processedErrors[getTypeOf(err)] = struct{}{}
+		if link.err == nil {
+			exceptions = append(exceptions, sentry.Exception{
+				Type:      chainTruncatedType,
+				Value:     fmt.Sprintf("error chain truncated after %d errors", c.cfg.MaxErrorDepth),
+				Mechanism: mechanism,
+			})
+			continue
+		}
+
+		_, mechanism.IsExceptionGroup = link.err.(interface{ Unwrap() []error })
 
-		exception := sentry.Exception{Value: err.Error(), Type: getTypeName(err)}
+		exception := sentry.Exception{
+			Value:     c.exceptionValue(link.err),
+			Type:      c.cfg.ExceptionTypeNamer.ExceptionTypeName(link.err),
+			Mechanism: mechanism,
+		}
 
 		if !c.cfg.DisableStacktrace {
-			stacktrace := sentry.ExtractStacktrace(err)
+			stacktrace := c.cfg.StacktraceExtractor.ExtractStacktrace(link.err)
 			if stacktrace != nil {
 				stacktrace.Frames = c.filterFrames(stacktrace.Frames)
 			}
@@ -228,26 +498,73 @@ func (c *core) addExceptionsFromError(
 		}
 
 		exceptions = append(exceptions, exception)
+	}
 
-		switch previousProvider := err.(type) {
-		case interface{ Unwrap() error }:
-			err = previousProvider.Unwrap()
-		case interface{ Cause() error }:
-			err = previousProvider.Cause()
-		default:
-			err = nil
+	return exceptions
+}
+
+func (c *core) exceptionValue(err error) string {
+	if _, ok := err.(fmt.Formatter); ok && c.cfg.VerboseExceptionValues {
+		return fmt.Sprintf("%+v", err)
+	}
+
+	return err.Error()
+}
+
+// addTitleException adds a synthetic primary exception typed by the message, according to Configuration.EventTitle.
+func (c *core) addTitleException(exceptions []sentry.Exception, message string) []sentry.Exception {
+	if len(exceptions) == 0 || message == "" || c.cfg.EventTitle == EventTitleException {
+		return exceptions
+	}
+
+	primary := &exceptions[len(exceptions)-1]
+
+	title := sentry.Exception{Type: message}
+	if c.cfg.EventTitle == EventTitleMessageAndError {
+		title.Value = primary.Value
+	}
+
+	if primary.Mechanism != nil {
+		// the title becomes the top-level exception 0, so shift the IDs of the chains
+		// and make the roots of all of them its causes.
+		titleID := 0
+		title.Mechanism = newMechanism(mechanismTitleKey, *primary.Mechanism.Handled, titleID, nil)
+
+		for i := range exceptions {
+			mechanism := exceptions[i].Mechanism
+			if mechanism == nil {
+				continue
+			}
+
+			mechanism.ExceptionID++
+			if mechanism.ParentID != nil {
+				*mechanism.ParentID++
+			} else {
+				parentID := titleID
+				mechanism.ParentID = &parentID
+				mechanism.Source = mechanismSourceCause
+			}
 		}
 	}
 
-	return exceptions
+	return append(exceptions, title)
 }
 
-func getTypeName(err error) string {
-	switch cast := err.(type) {
-	case interface{ TypeName() string }:
-		return cast.TypeName()
+// newMechanism describes an exception of the chain carried by the zap field with the given key.
+// Exception IDs follow the order of creation, the same way sentry-go does it.
+func newMechanism(key string, handled bool, id int, parentID *int) *sentry.Mechanism {
+	mechanism := &sentry.Mechanism{
+		Type:        mechanismType,
+		Handled:     &handled,
+		ExceptionID: id,
+		ParentID:    parentID,
+		Data:        map[string]interface{}{mechanismFieldKey: key},
 	}
-	return reflect.TypeOf(err).String()
+	if parentID != nil {
+		mechanism.Source = mechanismSourceCause
+	}
+
+	return mechanism
 }
 
 func (c *core) hub() *sentry.Hub {
@@ -277,17 +594,54 @@ func getScope(field zapcore.Field) *sentry.Scope {
 }
 
 func (c *core) Sync() error {
-	c.client.Flush(c.flushTimeout)
+	return c.syncError(c.flushed(c.client.Flush(c.flushTimeout)))
+}
+
+func (c *core) SyncContext(ctx context.Context) error {
+	return c.syncError(c.flushed(c.client.FlushWithContext(ctx)))
+}
+
+// syncError passes the flush error to Configuration.ErrorHandler and returns it
+// regardless of Configuration.ReturnErrors, as syncing is requested explicitly.
+func (c *core) syncError(err error) error {
+	if err != nil && c.cfg.ErrorHandler != nil {
+		c.cfg.ErrorHandler(err)
+	}
+
+	return err
+}
+
+func (c *core) flushed(ok bool) error {
+	if !ok {
+		c.state.stats.addFlushTimeout()
+		return &FlushTimeoutError{Pending: c.state.pending.Load()}
+	}
+
+	c.state.pending.Store(0)
 
 	return nil
 }
 
-func (c *core) with(fs []zapcore.Field) *core {
+func (c *core) Close(ctx context.Context) error {
+	if !c.state.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	err := c.SyncContext(ctx)
+
+	if c.state.ownsClient {
+		c.client.Close()
+	}
+
+	return err
+}
+
+func (c *core) with(fs []zapcore.Field) (*core, error) {
 	if len(fs) == 0 {
-		return c
+		return c, nil
 	}
 
-	errs := make([]error, len(c.errs))
+	errs := make([]errorField, len(c.errs))
 
 	copy(errs, c.errs)
 
@@ -298,17 +652,93 @@ func (c *core) with(fs []zapcore.Field) *core {
 	}
 
 	sentryScope := c.sentryScope
+	ctx := c.ctx
+	request := c.request
+	requestBodyData := c.requestBodyData
+	contexts := c.contexts
+	tags := c.tags
+	contextTags := c.contextTags
 	enc := zapcore.NewMapObjectEncoder()
 
+	// copy tags on write, as most of the fields aren't tags.
+	tagsCopied := false
+	setTag := func(key string, value fmt.Stringer) {
+		if !tagsCopied {
+			tags = make(map[string]fmt.Stringer, len(c.tags)+1)
+			for k, v := range c.tags {
+				tags[k] = v
+			}
+			tagsCopied = true
+		}
+		tags[key] = value
+	}
+
+	// copy contexts on write as well, merging the data of the same context.
+	contextsCopied := false
+	mergeContext := func(name string, data map[string]interface{}) {
+		if !contextsCopied {
+			contexts = make(map[string]sentry.Context, len(c.contexts)+1)
+			for k, v := range c.contexts {
+				contexts[k] = v
+			}
+			contextsCopied = true
+		}
+		merged := make(sentry.Context, len(contexts[name])+len(data))
+		for k, v := range contexts[name] {
+			merged[k] = v
+		}
+		for k, v := range data {
+			merged[k] = v
+		}
+		contexts[name] = merged
+	}
+
+	var encodeErrs []error
+
 	for _, f := range fs {
-		f.AddTo(enc)
+		if err := addField(enc, f); err != nil {
+			encodeErrs = append(encodeErrs, err)
+		}
 
 		if f.Type == zapcore.ErrorType {
-			errs = append(errs, f.Interface.(error))
+			if recovered, ok := f.Interface.(*recoveredPanic); ok {
+				errs = append(errs, errorField{key: f.Key, err: recovered.err, recovered: true})
+			} else {
+				errs = append(errs, errorField{key: f.Key, err: f.Interface.(error)})
+			}
 		} else if errSlice, ok := f.Interface.([]error); ok {
-			errs = append(errs, errSlice...)
+			for _, err := range errSlice {
+				errs = append(errs, errorField{key: f.Key, err: err})
+			}
 		} else if scope := getScope(f); scope != nil {
 			sentryScope = scope
+		} else if f.Type == zapcore.SkipType {
+			switch t := f.Interface.(type) {
+			case tagField:
+				setTag(t.Key, stringTag(t.Value))
+			case stringerTagField:
+				setTag(t.Key, t.Value)
+			case tagsField:
+				for k, v := range t {
+					setTag(k, stringTag(v))
+				}
+			case contextTagField:
+				contextTags = append(contextTags[:len(contextTags):len(contextTags)], t)
+			case ctxField:
+				ctx = t.Value
+			case requestField:
+				request = t.Value
+			case requestBodyField:
+				requestBodyData = t.Value
+			case contextDataField:
+				mergeContext(t.Name, t.Data)
+			case contextObjectField:
+				data, err := marshalContext(t.Name, t.Object)
+				if err != nil {
+					encodeErrs = append(encodeErrs, err)
+				}
+				mergeContext(t.Name, data)
+			}
 		}
 	}
 
@@ -317,30 +747,92 @@ func (c *core) with(fs []zapcore.Field) *core {
 	}
 
 	return &core{
-		client:       c.client,
-		cfg:          c.cfg,
-		LevelEnabler: c.LevelEnabler,
-		flushTimeout: c.flushTimeout,
-		sentryScope:  sentryScope,
-		errs:         errs,
-		fields:       fields,
+		client:          c.client,
+		cfg:             c.cfg,
+		LevelEnabler:    c.LevelEnabler,
+		flushTimeout:    c.flushTimeout,
+		state:           c.state,
+		sentryScope:     sentryScope,
+		ctx:             ctx,
+		request:         request,
+		requestBodyData: requestBodyData,
+		contexts:        contexts,
+		errs:            errs,
+		fields:          fields,
+		tags:            tags,
+		contextTags:     contextTags,
+	}, errors.Join(encodeErrs...)
+}
+
+// addField adds the field to the encoder, recovering from panics of custom marshalers.
+func addField(enc zapcore.ObjectEncoder, f zapcore.Field) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("encode field %q: panic: %v", f.Key, r)
+		}
+	}()
+
+	f.AddTo(enc)
+
+	return nil
+}
+
+// context returns the context passed with Context or else the one of the request passed with Request.
+func (c *core) context() context.Context {
+	if c.ctx == nil && c.request != nil {
+		return c.request.Context()
 	}
+
+	return c.ctx
 }
 
 func (c *core) GetClient() *sentry.Client {
 	return c.client
 }
 
+func (c *core) Stats() Stats {
+	return c.state.stats.snapshot()
+}
+
 type core struct {
 	client *sentry.Client
 	cfg    *Configuration
 	zapcore.LevelEnabler
 	flushTimeout time.Duration
+	state        *state
 
 	sentryScope *sentry.Scope
+	ctx         context.Context
+	request     *http.Request
+	// requestBodyData is the body passed with RequestBody.
+	requestBodyData []byte
+
+	errs        []errorField
+	fields      map[string]interface{}
+	tags        map[string]fmt.Stringer
+	contextTags []contextTagField
+	contexts    map[string]sentry.Context
+}
+
+// errorField is an error with the key of the zap field carrying it.
+type errorField struct {
+	key string
+	err error
+	// recovered is set for panics reported with Recovered.
+	recovered bool
+}
+
+// state is shared by the core and all its clones.
+type state struct {
+	// pending is the number of events captured since the last successful flush.
+	pending atomic.Int64
+
+	closed atomic.Bool
+	stats  *stats
 
-	errs   []error
-	fields map[string]interface{}
+	ownsClient bool
+	// modulesIntegration is set if the client adds modules to events by itself.
+	modulesIntegration bool
 }
 
 // follow same logic with sentry-go to filter unnecessary frames
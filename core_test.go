@@ -0,0 +1,429 @@
+package zapsentry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func TestSyncFlushTimeout(t *testing.T) {
+	core, transport := zapsentrytest.NewCore(t, zapsentry.Configuration{
+		Level:        zapcore.ErrorLevel,
+		ErrorHandler: func(error) {},
+	})
+	logger := zap.New(core)
+
+	logger.Error("first")
+	logger.Error("second")
+	transport.FailFlush(true)
+
+	err := core.Sync()
+	if !errors.Is(err, zapsentry.ErrFlushTimeout) {
+		t.Fatalf("expected ErrFlushTimeout, got %v", err)
+	}
+	var timeoutErr *zapsentry.FlushTimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Pending != 2 {
+		t.Errorf("expected 2 pending events, got %v", err)
+	}
+
+	transport.FailFlush(false)
+	if err := core.(zapsentry.ContextSyncer).SyncContext(context.Background()); err != nil {
+		t.Errorf("expected successful flush, got %v", err)
+	}
+
+	transport.FailFlush(true)
+	if err := core.Sync(); err == nil || err.Error() != "sentry flush timed out with 0 events pending" {
+		t.Errorf("expected no pending events after successful flush, got %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		newCore    func(transport sentry.Transport) (zapcore.Core, error)
+		wantClosed bool
+	}{
+		{
+			name: "owned client",
+			newCore: func(transport sentry.Transport) (zapcore.Core, error) {
+				return zapsentry.NewCore(
+					zapsentry.Configuration{Level: zapcore.ErrorLevel},
+					zapsentry.NewSentryClientFromOptions(sentry.ClientOptions{Transport: transport}),
+				)
+			},
+			wantClosed: true,
+		},
+		{
+			name: "borrowed client",
+			newCore: func(transport sentry.Transport) (zapcore.Core, error) {
+				client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+				if err != nil {
+					return nil, err
+				}
+				return zapsentry.NewCore(
+					zapsentry.Configuration{Level: zapcore.ErrorLevel},
+					zapsentry.NewSentryClientFromClient(client),
+				)
+			},
+			wantClosed: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := zapsentrytest.NewTransport()
+			core, err := tt.newCore(transport)
+			if err != nil {
+				t.Fatal(err)
+			}
+			logger := zap.New(core).With(zap.String("key", "value"))
+
+			logger.Error("before close")
+			if err := core.(zapsentry.Closer).Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			logger.Error("after close")
+
+			transport.AssertEventCount(t, 1)
+			if transport.Closed() != tt.wantClosed {
+				t.Errorf("expected transport closed to be %v", tt.wantClosed)
+			}
+		})
+	}
+}
+
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalLogObject(zapcore.ObjectEncoder) error {
+	panic("boom")
+}
+
+func TestErrorHandler(t *testing.T) {
+	var handled []error
+	transport := zapsentrytest.NewTransport()
+	client := zapsentrytest.NewClient(transport, sentry.ClientOptions{
+		BeforeSend: func(*sentry.Event, *sentry.EventHint) *sentry.Event { return nil },
+	})
+	core, err := zapsentry.NewCore(zapsentry.Configuration{
+		Level:        zapcore.ErrorLevel,
+		ErrorHandler: func(err error) { handled = append(handled, err) },
+		ReturnErrors: true,
+	}, zapsentry.NewSentryClientFromClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "rejected"}, []zapcore.Field{
+		zap.Object("payload", panickingMarshaler{}),
+	})
+	if !errors.Is(err, zapsentry.ErrEventDropped) {
+		t.Errorf("expected ErrEventDropped, got %v", err)
+	}
+	if len(handled) != 2 || !errors.Is(handled[1], zapsentry.ErrEventDropped) {
+		t.Fatalf("expected encoding and dropped event errors, got %v", handled)
+	}
+	if handled[0].Error() != `encode field "payload": panic: boom` {
+		t.Errorf("unexpected encoding error %v", handled[0])
+	}
+
+	_, err = zapsentry.NewCore(zapsentry.Configuration{
+		ErrorHandler: func(err error) { handled = append(handled, err) },
+	}, func() (*sentry.Client, error) {
+		return nil, errors.New("no client")
+	})
+	if err == nil || len(handled) != 3 {
+		t.Errorf("expected factory error to be handled, got %v", handled)
+	}
+}
+
+func TestWriteFlushTimeout(t *testing.T) {
+	for _, returnErrors := range []bool{false, true} {
+		var handled []error
+		core, transport := zapsentrytest.NewCore(t, zapsentry.Configuration{
+			Level:        zapcore.ErrorLevel,
+			ErrorHandler: func(err error) { handled = append(handled, err) },
+			ReturnErrors: returnErrors,
+		})
+		transport.FailFlush(true)
+
+		err := core.Write(zapcore.Entry{Level: zapcore.DPanicLevel, Message: "crashing"}, nil)
+
+		if len(handled) != 1 || !errors.Is(handled[0], zapsentry.ErrFlushTimeout) {
+			t.Errorf("expected the flush timeout to be handled once, got %v", handled)
+		}
+		if got := errors.Is(err, zapsentry.ErrFlushTimeout); got != returnErrors {
+			t.Errorf("expected the flush timeout to be returned only with ReturnErrors, got %v", err)
+		}
+	}
+}
+
+type cyclicError struct{ cause error }
+
+func (e *cyclicError) Error() string { return "cyclic" }
+func (e *cyclicError) Unwrap() error { return e.cause }
+
+func TestErrorChain(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		DisableStacktrace: true,
+		MaxErrorDepth:     3,
+	})
+
+	// same text and type, but different errors.
+	logger.Error("same text", zap.NamedError("first", errors.New("retry")), zap.NamedError("second", errors.New("retry")))
+	if exceptions := transport.LastEvent().Exceptions(); len(exceptions) != 2 {
+		t.Errorf("expected two exceptions, got %v", exceptions)
+	}
+
+	cyclic := &cyclicError{}
+	cyclic.cause = &cyclicError{cause: cyclic}
+	logger.Error("cycle", zap.Error(cyclic))
+	if exceptions := transport.LastEvent().Exceptions(); len(exceptions) != 2 {
+		t.Errorf("expected two exceptions, got %v", exceptions)
+	}
+
+	err := errors.New("root")
+	for i := 0; i < 5; i++ {
+		err = fmt.Errorf("retry %d: %w", i, err)
+	}
+	logger.Error("deep", zap.Error(err))
+	exceptions := transport.LastEvent().Exceptions()
+	if len(exceptions) != 4 {
+		t.Fatalf("expected three exceptions and a truncation marker, got %v", exceptions)
+	}
+	if exceptions[0].Type != "ChainTruncated" || exceptions[3].Value != err.Error() {
+		t.Errorf("unexpected exceptions %v", exceptions)
+	}
+}
+
+func TestExceptionMechanism(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		DisableStacktrace: true,
+	})
+
+	logger.Error("logged", zap.NamedError("cause", fmt.Errorf("wrapped: %w", errors.New("root"))))
+	exceptions := transport.LastEvent().Exceptions()
+	if len(exceptions) != 2 {
+		t.Fatalf("expected two exceptions, got %v", exceptions)
+	}
+	for _, exception := range exceptions {
+		mechanism := exception.Mechanism
+		if mechanism == nil || mechanism.Type != "zap" || !*mechanism.Handled || mechanism.Data["field"] != "cause" {
+			t.Errorf("unexpected mechanism %+v", mechanism)
+		}
+	}
+	if exceptions[1].Mechanism.ParentID != nil || *exceptions[0].Mechanism.ParentID != exceptions[1].Mechanism.ExceptionID {
+		t.Errorf("expected the root error to be the child of the wrapper")
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("recovered", zapsentry.Recovered(r))
+			}
+		}()
+		logger.Panic("crash", zap.Error(errors.New("fatal")))
+	}()
+
+	crash := transport.FindEvent("crash").Exceptions()
+	if len(crash) != 1 || *crash[0].Mechanism.Handled {
+		t.Errorf("expected unhandled exception for panic entry, got %v", crash)
+	}
+	recovered := transport.FindEvent("recovered").Exceptions()
+	if len(recovered) != 1 || *recovered[0].Mechanism.Handled || recovered[0].Mechanism.Data["field"] != "panic" {
+		t.Errorf("expected unhandled exception for recovered panic, got %v", recovered)
+	}
+	if recovered[0].Type != "panic" || recovered[0].Value != "crash" {
+		t.Errorf("unexpected recovered panic exception %+v", recovered[0])
+	}
+}
+
+func TestWithErrorsPolicy(t *testing.T) {
+	requestErr := errors.New("request failed")
+	entryErr := errors.New("write failed")
+
+	for _, tt := range []struct {
+		name       string
+		policy     zapsentry.WithErrorsPolicy
+		primaryKey string
+		withEntry  []string
+		noEntry    []string
+	}{
+		{
+			name:      "as exceptions",
+			policy:    zapsentry.WithErrorsAsExceptions,
+			withEntry: []string{"request failed", "write failed"},
+			noEntry:   []string{"request failed"},
+		},
+		{
+			name:      "as context",
+			policy:    zapsentry.WithErrorsAsContext,
+			withEntry: []string{"write failed"},
+			noEntry:   nil,
+		},
+		{
+			name:      "as fallback",
+			policy:    zapsentry.WithErrorsAsFallback,
+			withEntry: []string{"write failed"},
+			noEntry:   []string{"request failed"},
+		},
+		{
+			name:       "primary error key",
+			policy:     zapsentry.WithErrorsAsExceptions,
+			primaryKey: "request",
+			withEntry:  []string{"write failed", "request failed"},
+			noEntry:    []string{"request failed"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+				Level:             zapcore.ErrorLevel,
+				DisableStacktrace: true,
+				WithErrors:        tt.policy,
+				PrimaryErrorKey:   tt.primaryKey,
+			})
+			logger = logger.With(zap.NamedError("request", requestErr))
+
+			logger.Error("with entry error", zap.Error(entryErr))
+			logger.Error("without entry error")
+
+			for message, want := range map[string][]string{
+				"with entry error":    tt.withEntry,
+				"without entry error": tt.noEntry,
+			} {
+				event := transport.FindEvent(message)
+				var got []string
+				for _, exception := range event.Exceptions() {
+					got = append(got, exception.Value)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("%s: expected exceptions %v, got %v", message, want, got)
+				}
+				if event.Extra()["request"] != "request failed" {
+					t.Errorf("%s: expected the error in extra fields, got %v", message, event.Extra())
+				}
+			}
+		})
+	}
+}
+
+type verboseError struct{}
+
+func (verboseError) Error() string { return "short" }
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = io.WriteString(s, "short\ndetailed")
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
+
+func TestEventTitle(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		title     zapsentry.EventTitle
+		verbose   bool
+		wantType  string
+		wantValue string
+		wantCount int
+	}{
+		{name: "exception", title: zapsentry.EventTitleException, wantType: "zapsentry_test.verboseError", wantValue: "short", wantCount: 1},
+		{name: "message", title: zapsentry.EventTitleMessage, wantType: "saving failed", wantValue: "", wantCount: 2},
+		{name: "message and error", title: zapsentry.EventTitleMessageAndError, wantType: "saving failed", wantValue: "short", wantCount: 2},
+		{name: "verbose", verbose: true, wantType: "zapsentry_test.verboseError", wantValue: "short\ndetailed", wantCount: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+				Level:                  zapcore.ErrorLevel,
+				EventTitle:             tt.title,
+				VerboseExceptionValues: tt.verbose,
+			})
+
+			logger.Error("saving failed", zap.Error(verboseError{}))
+
+			exceptions := transport.LastEvent().Exceptions()
+			if len(exceptions) != tt.wantCount {
+				t.Fatalf("expected %d exceptions, got %v", tt.wantCount, exceptions)
+			}
+			primary := exceptions[len(exceptions)-1]
+			if primary.Type != tt.wantType || primary.Value != tt.wantValue {
+				t.Errorf("unexpected primary exception %q: %q", primary.Type, primary.Value)
+			}
+			if tt.wantCount == 2 && *exceptions[0].Mechanism.ParentID != primary.Mechanism.ExceptionID {
+				t.Errorf("expected the error to be the cause of the title exception")
+			}
+		})
+	}
+}
+
+func TestEventTitleExceptionIDs(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		EventTitle:        zapsentry.EventTitleMessage,
+		DisableStacktrace: true,
+	})
+
+	logger.Error("saving failed",
+		zap.NamedError("a", fmt.Errorf("A: %w", errors.New("root"))),
+		zap.NamedError("b", errors.New("B")),
+	)
+
+	exceptions := transport.LastEvent().Exceptions()
+	if len(exceptions) != 4 {
+		t.Fatalf("expected 4 exceptions, got %v", exceptions)
+	}
+
+	// IDs increase backwards through the list, the title being the top-level exception 0.
+	parents := make(map[string]int)
+	for i, exception := range exceptions {
+		mechanism := exception.Mechanism
+		if mechanism.ExceptionID != len(exceptions)-1-i {
+			t.Errorf("expected exception %q to have ID %d, got %d", exception.Value, len(exceptions)-1-i, mechanism.ExceptionID)
+		}
+		if mechanism.ParentID != nil {
+			parents[exception.Type+exception.Value] = *mechanism.ParentID
+		}
+	}
+
+	want := map[string]int{
+		"*errors.errorString" + "root": 2,
+		"*fmt.wrapError" + "A: root":   0,
+		"*errors.errorString" + "B":    0,
+	}
+	if fmt.Sprint(parents) != fmt.Sprint(want) {
+		t.Errorf("expected parents %v, got %v", want, parents)
+	}
+}
+
+func TestLoggerName(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:            zapcore.ErrorLevel,
+		LoggerNameTagKey: "logger",
+	})
+
+	logger.Named("svc").Named("db").Named("pool").Error("named")
+	logger.Error("unnamed")
+
+	event := transport.FindEvent("named")
+	if event.Logger != "svc.db.pool" {
+		t.Errorf("expected logger svc.db.pool, got %q", event.Logger)
+	}
+	want := map[string]string{"logger": "svc.db.pool", "logger.1": "svc", "logger.2": "svc.db"}
+	if !reflect.DeepEqual(event.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, event.Tags)
+	}
+
+	if event := transport.FindEvent("unnamed"); event.Logger != "" || len(event.Tags) != 0 {
+		t.Errorf("expected no logger name, got %q and tags %v", event.Logger, event.Tags)
+	}
+}
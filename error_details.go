@@ -0,0 +1,147 @@
+package zapsentry
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net"
+	"net/url"
+	"os/exec"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const errorDetailsContextKey = "error_details"
+
+type (
+	ErrorDetailsExtractorFunc func(err error) (name string, details map[string]interface{})
+)
+
+// ErrorDetailsExtractor extracts structured details from errors of a certain type.
+// It is called for every error in the chain, the outermost first, and returns
+// an empty name if it doesn't recognize the error; the causes are checked separately.
+// Details are added to the "error_details" context of sentry.Event(s) under the name.
+type ErrorDetailsExtractor interface {
+	ErrorDetails(err error) (name string, details map[string]interface{})
+}
+
+var errorDetailsExtractors = struct {
+	sync.RWMutex
+	list []ErrorDetailsExtractor
+}{
+	list: []ErrorDetailsExtractor{
+		ErrorDetailsOf("url", func(err *url.Error) map[string]interface{} {
+			return map[string]interface{}{"op": err.Op, "url": err.URL, "timeout": err.Timeout()}
+		}),
+		ErrorDetailsOf("net", func(err *net.OpError) map[string]interface{} {
+			details := map[string]interface{}{"op": err.Op, "net": err.Net, "timeout": err.Timeout()}
+			if err.Source != nil {
+				details["source"] = err.Source.String()
+			}
+			if err.Addr != nil {
+				details["addr"] = err.Addr.String()
+			}
+			return details
+		}),
+		ErrorDetailsOf("dns", func(err *net.DNSError) map[string]interface{} {
+			return map[string]interface{}{
+				"name":      err.Name,
+				"server":    err.Server,
+				"timeout":   err.IsTimeout,
+				"not_found": err.IsNotFound,
+			}
+		}),
+		ErrorDetailsOf("path", func(err *fs.PathError) map[string]interface{} {
+			return map[string]interface{}{"op": err.Op, "path": err.Path}
+		}),
+		ErrorDetailsOf("exec", func(err *exec.ExitError) map[string]interface{} {
+			return map[string]interface{}{"exit_code": err.ExitCode(), "pid": err.Pid(), "state": err.String()}
+		}),
+		ErrorDetailsOf("json", func(err *json.SyntaxError) map[string]interface{} {
+			return map[string]interface{}{"position": err.Offset}
+		}),
+		ErrorDetailsOf("json", func(err *json.UnmarshalTypeError) map[string]interface{} {
+			details := map[string]interface{}{
+				"position": err.Offset,
+				"value":    err.Value,
+				"struct":   err.Struct,
+				"field":    err.Field,
+			}
+			if err.Type != nil {
+				details["type"] = err.Type.String()
+			}
+			return details
+		}),
+		ErrorDetailsExtractorFunc(func(err error) (string, map[string]interface{}) {
+			if err == context.DeadlineExceeded {
+				return "context", map[string]interface{}{"deadline_exceeded": true}
+			}
+			return "", nil
+		}),
+	},
+}
+
+func (f ErrorDetailsExtractorFunc) ErrorDetails(err error) (string, map[string]interface{}) {
+	return f(err)
+}
+
+// ErrorDetailsOf makes an extractor of details from errors of type T.
+func ErrorDetailsOf[T error](name string, details func(err T) map[string]interface{}) ErrorDetailsExtractor {
+	return ErrorDetailsExtractorFunc(func(err error) (string, map[string]interface{}) {
+		if target, ok := err.(T); ok {
+			return name, details(target)
+		}
+		return "", nil
+	})
+}
+
+// RegisterErrorDetailsExtractor adds the extractor to the ones used by all cores.
+// Extractors are tried in the order of registration, the built-in ones first;
+// only the first details extracted under each name are kept.
+// It's supposed to be called on initialization, e.g. from init functions.
+func RegisterErrorDetailsExtractor(extractor ErrorDetailsExtractor) {
+	errorDetailsExtractors.Lock()
+	defer errorDetailsExtractors.Unlock()
+
+	errorDetailsExtractors.list = append(errorDetailsExtractors.list, extractor)
+}
+
+// addErrorDetails adds the "error_details" context, if any extractor recognizes the errors.
+func (c *core) addErrorDetails(event *sentry.Event) {
+	if len(c.errs) == 0 {
+		return
+	}
+
+	errorDetailsExtractors.RLock()
+	defer errorDetailsExtractors.RUnlock()
+
+	var errorDetails sentry.Context
+
+	// the chain is walked here rather than with errors.As to be safe from cycles.
+	for _, field := range c.errs {
+		for _, link := range c.errorChain(field.err, nil) {
+			if link.err == nil {
+				continue
+			}
+
+			for _, extractor := range errorDetailsExtractors.list {
+				name, details := extractor.ErrorDetails(link.err)
+				if name == "" {
+					continue
+				}
+				if _, ok := errorDetails[name]; ok {
+					continue
+				}
+				if errorDetails == nil {
+					errorDetails = make(sentry.Context)
+				}
+				errorDetails[name] = details
+			}
+		}
+	}
+
+	if errorDetails != nil {
+		event.Contexts[errorDetailsContextKey] = errorDetails
+	}
+}
@@ -0,0 +1,95 @@
+package zapsentry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+type quotaError struct {
+	limit int
+}
+
+func (e *quotaError) Error() string { return fmt.Sprintf("quota of %d exceeded", e.limit) }
+
+func init() {
+	zapsentry.RegisterErrorDetailsExtractor(zapsentry.ErrorDetailsOf("quota", func(err *quotaError) map[string]interface{} {
+		return map[string]interface{}{"limit": err.limit}
+	}))
+}
+
+func TestErrorDetails(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	dnsErr := &net.DNSError{Name: "example.invalid", Server: "127.0.0.53:53", IsNotFound: true}
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: dnsErr}
+	urlErr := &url.Error{Op: "Get", URL: "http://example.invalid/", Err: opErr}
+	logger.Error("request failed", zap.Error(fmt.Errorf("fetch: %w", urlErr)), zap.NamedError("quota", &quotaError{limit: 10}))
+
+	details := transport.LastEvent().Contexts["error_details"]
+	want := map[string]map[string]interface{}{
+		"url":   {"op": "Get", "url": "http://example.invalid/"},
+		"net":   {"op": "dial", "net": "tcp"},
+		"dns":   {"name": "example.invalid", "not_found": true},
+		"quota": {"limit": 10},
+	}
+	for name, fields := range want {
+		got, ok := details[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("expected %s details, got %v", name, details)
+			continue
+		}
+		for k, v := range fields {
+			if got[k] != v {
+				t.Errorf("expected %s.%s=%v, got %v", name, k, v, got[k])
+			}
+		}
+	}
+
+	_, pathErr := os.Open("/nonexistent")
+	jsonErr := json.Unmarshal([]byte("{"), &struct{}{})
+	logger.Error("io failed",
+		zap.Error(fmt.Errorf("load: %w", pathErr)),
+		zap.NamedError("decode", jsonErr),
+		zap.NamedError("ctx", context.DeadlineExceeded),
+	)
+
+	details = transport.LastEvent().Contexts["error_details"]
+	for _, name := range []string{"path", "json", "context"} {
+		if _, ok := details[name]; !ok {
+			t.Errorf("expected %s details, got %v", name, details)
+		}
+	}
+
+	logger.Error("plain", zap.Error(errors.New("boom")))
+	if _, ok := transport.LastEvent().Contexts["error_details"]; ok {
+		t.Error("expected no details for plain errors")
+	}
+}
+
+func TestErrorDetailsOfJoinedErrors(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	_, pathErr := os.Open("/nonexistent")
+	joined := errors.Join(errors.New("other"), fmt.Errorf("cleanup: %w", pathErr), &quotaError{limit: 3})
+	logger.Error("cleanup failed", zap.Error(fmt.Errorf("shutdown: %w", joined)))
+
+	details := transport.LastEvent().Contexts["error_details"]
+	if path, ok := details["path"].(map[string]interface{}); !ok || path["path"] != "/nonexistent" {
+		t.Errorf("expected path details of the joined error, got %v", details)
+	}
+	if quota, ok := details["quota"].(map[string]interface{}); !ok || quota["limit"] != 3 {
+		t.Errorf("expected quota details of the joined error, got %v", details)
+	}
+}
@@ -0,0 +1,165 @@
+package zapsentry
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// TagsProvider is implemented by errors contributing tags to sentry.Event(s).
+// Tags of outer errors in the chain override the ones of inner errors,
+// tags added with Tag override both.
+type TagsProvider interface {
+	SentryTags() map[string]string
+}
+
+// ContextProvider is implemented by errors contributing a named context to sentry.Event(s).
+// Data of errors providing the same context name is merged, outer errors in the chain take precedence.
+type ContextProvider interface {
+	SentryContext() (name string, data map[string]interface{})
+}
+
+// FingerprintProvider is implemented by errors controlling the grouping of sentry.Event(s).
+// The outermost error in the chain providing a non-empty fingerprint takes precedence.
+type FingerprintProvider interface {
+	SentryFingerprint() []string
+}
+
+// LevelProvider is implemented by errors overriding the level of sentry.Event(s),
+// e.g. to report expected errors as warnings.
+// The outermost error in the chain providing a non-empty level takes precedence.
+type LevelProvider interface {
+	SentryLevel() sentry.Level
+}
+
+// chainedError is an error of the chain walked by errorChain.
+type chainedError struct {
+	// err is nil for the marker ending a chain truncated by Configuration.MaxErrorDepth.
+	err error
+	// parent is the index of the wrapping error in the chain, -1 for the outermost error.
+	parent int
+	// joinIndex is the index of the error among the ones joined by the parent, -1 for a wrapped error.
+	joinIndex int
+}
+
+// errorChain returns the errors of the chain, the outermost one first, each one with its parent.
+// Errors joined with errors.Join and the like are walked in order, each one with its own chain first,
+// the same way errors.As does it. Chains of more than Configuration.MaxErrorDepth errors end with a marker.
+// Errors in seen are skipped with their chains and the walked ones are added to it,
+// so that cycles and errors shared by several chains are reported once.
+func (c *core) errorChain(err error, seen map[error]struct{}) []chainedError {
+	var (
+		chain     []chainedError
+		truncated bool
+		visit     func(err error, parent, joinIndex int)
+	)
+
+	visit = func(err error, parent, joinIndex int) {
+		for err != nil && !truncated {
+			// errors of non-comparable types are values, so they can't form cycles.
+			if isComparable(err) {
+				if _, ok := seen[err]; ok {
+					return
+				}
+
+				seen[err] = struct{}{}
+			}
+
+			if len(chain) == c.cfg.MaxErrorDepth {
+				chain = append(chain, chainedError{parent: parent, joinIndex: joinIndex})
+				truncated = true
+				return
+			}
+
+			chain = append(chain, chainedError{err: err, parent: parent, joinIndex: joinIndex})
+			parent, joinIndex = len(chain)-1, -1
+
+			switch previousProvider := err.(type) {
+			case interface{ Unwrap() error }:
+				err = previousProvider.Unwrap()
+			case interface{ Cause() error }:
+				err = previousProvider.Cause()
+			case interface{ Unwrap() []error }:
+				for i, joined := range previousProvider.Unwrap() {
+					visit(joined, parent, i)
+				}
+				return
+			default:
+				return
+			}
+		}
+	}
+
+	if seen == nil {
+		seen = make(map[error]struct{})
+	}
+	visit(err, -1, -1)
+
+	return chain
+}
+
+// walkErrors calls fn for every error in the chains of the fields,
+// the innermost errors of every chain and the oldest chains first.
+func (c *core) walkErrors(fields []errorField, fn func(err error)) {
+	for _, field := range fields {
+		chain := c.errorChain(field.err, nil)
+		for i := len(chain) - 1; i >= 0; i-- {
+			if chain[i].err != nil {
+				fn(chain[i].err)
+			}
+		}
+	}
+}
+
+// applyErrorProviders adds tags and contexts provided by all the errors to the event,
+// and fingerprint and level provided by the errors reported as exceptions.
+func (c *core) applyErrorProviders(event *sentry.Event, exceptionErrs []errorField) {
+	c.walkErrors(exceptionErrs, func(err error) {
+		if provider, ok := err.(FingerprintProvider); ok {
+			if fingerprint := provider.SentryFingerprint(); len(fingerprint) > 0 {
+				event.Fingerprint = fingerprint
+			}
+		}
+
+		if provider, ok := err.(LevelProvider); ok {
+			if level := provider.SentryLevel(); level != "" {
+				event.Level = level
+			}
+		}
+	})
+
+	var contexts map[string]sentry.Context
+
+	c.walkErrors(c.errs, func(err error) {
+		if provider, ok := err.(TagsProvider); ok {
+			for k, v := range provider.SentryTags() {
+				event.Tags[k] = v
+			}
+		}
+
+		if provider, ok := err.(ContextProvider); ok {
+			name, data := provider.SentryContext()
+			if name == "" || len(data) == 0 {
+				return
+			}
+
+			if contexts == nil {
+				contexts = make(map[string]sentry.Context)
+			}
+			merged, ok := contexts[name]
+			if !ok {
+				// don't modify contexts shared with the core, e.g. "Extra".
+				merged = make(sentry.Context, len(event.Contexts[name])+len(data))
+				for k, v := range event.Contexts[name] {
+					merged[k] = v
+				}
+				contexts[name] = merged
+			}
+			for k, v := range data {
+				merged[k] = v
+			}
+		}
+	})
+
+	for name, data := range contexts {
+		event.Contexts[name] = data
+	}
+}
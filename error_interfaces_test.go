@@ -0,0 +1,161 @@
+package zapsentry_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+type orderError struct {
+	orderID string
+	tenant  string
+	cause   error
+}
+
+func (e *orderError) Error() string { return "order " + e.orderID + ": " + e.cause.Error() }
+func (e *orderError) Unwrap() error { return e.cause }
+
+func (e *orderError) SentryTags() map[string]string {
+	return map[string]string{"tenant": e.tenant, "source": "order"}
+}
+
+func (e *orderError) SentryContext() (string, map[string]interface{}) {
+	return "order", map[string]interface{}{"id": e.orderID}
+}
+
+type upstreamError struct {
+	status int
+}
+
+func (e *upstreamError) Error() string { return fmt.Sprintf("upstream status %d", e.status) }
+
+func (e *upstreamError) SentryTags() map[string]string {
+	return map[string]string{"source": "upstream"}
+}
+
+func (e *upstreamError) SentryContext() (string, map[string]interface{}) {
+	return "order", map[string]interface{}{"id": "unknown", "upstream_status": e.status}
+}
+
+func TestErrorProviders(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level: zapcore.ErrorLevel,
+		Tags:  map[string]string{"tenant": "default", "component": "system"},
+	})
+
+	err := fmt.Errorf("checkout: %w", &orderError{orderID: "42", tenant: "acme", cause: &upstreamError{status: 503}})
+	logger.Error("failed", zap.Error(err), zapsentry.Tag("component", "checkout"))
+
+	event := transport.LastEvent()
+	wantTags := map[string]string{"tenant": "acme", "source": "order", "component": "checkout"}
+	for k, v := range wantTags {
+		if event.Tags[k] != v {
+			t.Errorf("expected tag %s=%s, got %q", k, v, event.Tags[k])
+		}
+	}
+	order := event.Contexts["order"]
+	if order["id"] != "42" || order["upstream_status"] != 503 {
+		t.Errorf("unexpected order context %v", order)
+	}
+
+	logger.Error("plain", zap.Error(errors.New("boom")))
+	if _, ok := transport.LastEvent().Contexts["order"]; ok {
+		t.Error("expected no order context for plain errors")
+	}
+}
+
+type validationError struct {
+	field string
+}
+
+func (e *validationError) Error() string { return "invalid " + e.field }
+
+func (e *validationError) SentryLevel() sentry.Level { return sentry.LevelWarning }
+
+func (e *validationError) SentryFingerprint() []string {
+	return []string{"validation", e.field}
+}
+
+func TestErrorFingerprintAndLevel(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	logger.Error("rejected", zap.Error(fmt.Errorf("request 7: %w", &validationError{field: "email"})))
+
+	event := transport.LastEvent()
+	if event.Level != sentry.LevelWarning {
+		t.Errorf("expected warning level, got %v", event.Level)
+	}
+	if !reflect.DeepEqual(event.Fingerprint, []string{"validation", "email"}) {
+		t.Errorf("unexpected fingerprint %v", event.Fingerprint)
+	}
+
+	logger.Error("failed", zap.Error(errors.New("boom")))
+	if event := transport.LastEvent(); event.Level != sentry.LevelError || event.Fingerprint != nil {
+		t.Errorf("expected defaults for plain errors, got %v %v", event.Level, event.Fingerprint)
+	}
+}
+
+func TestErrorProvidersOfJoinedErrors(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	joined := errors.Join(
+		&orderError{orderID: "42", tenant: "acme", cause: errors.New("declined")},
+		&upstreamError{status: 502},
+	)
+	logger.Error("checkout failed", zap.Error(joined))
+
+	event := transport.LastEvent()
+	if want := map[string]string{"tenant": "acme", "source": "order"}; !reflect.DeepEqual(event.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, event.Tags)
+	}
+	if want := (map[string]interface{}{"id": "42", "upstream_status": 502}); !reflect.DeepEqual(map[string]interface{}(event.Contexts["order"]), want) {
+		t.Errorf("expected order context %v, got %v", want, event.Contexts["order"])
+	}
+}
+
+func TestExceptionsOfJoinedErrors(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		DisableStacktrace: true,
+	})
+
+	logger.Error("rejected", zap.Error(errors.Join(errors.New("a"), &validationError{field: "email"})))
+
+	event := transport.LastEvent()
+	exceptions := event.Exceptions()
+	if len(exceptions) != 3 {
+		t.Fatalf("expected the join and both joined errors, got %v", exceptions)
+	}
+
+	join, validation := exceptions[2], exceptions[0]
+	if !join.Mechanism.IsExceptionGroup || join.Mechanism.ExceptionID != 0 || join.Mechanism.ParentID != nil {
+		t.Errorf("expected the join to be the top-level exception group, got %+v", join.Mechanism)
+	}
+	if validation.Value != "invalid email" || *validation.Mechanism.ParentID != 0 || validation.Mechanism.Source != "errors[1]" {
+		t.Errorf("expected the validation error to be the second joined one, got %q %+v", validation.Value, validation.Mechanism)
+	}
+	if !reflect.DeepEqual(event.Fingerprint, []string{"validation", "email"}) {
+		t.Errorf("unexpected fingerprint %v", event.Fingerprint)
+	}
+}
+
+type selfJoinedError struct{}
+
+func (e *selfJoinedError) Error() string   { return "self joined" }
+func (e *selfJoinedError) Unwrap() []error { return []error{e, e} }
+
+func TestErrorProvidersOfCyclicJoinedErrors(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	logger.Error("cyclic", zap.Error(&selfJoinedError{}))
+
+	transport.AssertEventCount(t, 1)
+}
@@ -1,10 +1,8 @@
 package zapsentry_test
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/getsentry/sentry-go"
 	"go.uber.org/zap"
@@ -12,6 +10,7 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
 )
 
 func ExampleAttachCoreToLogger() {
@@ -23,10 +22,8 @@ func ExampleAttachCoreToLogger() {
 	logger := zap.New(core, zap.AddStacktrace(zap.DebugLevel))
 
 	// Setup mock sentry client for testing, in general we use sentry.NewClient
-	var recordedSentryEvent *sentry.Event
-	sentryClient := mockSentryClient(func(event *sentry.Event) {
-		recordedSentryEvent = event
-	})
+	transport := zapsentrytest.NewTransport()
+	sentryClient := zapsentrytest.NewClient(transport, sentry.ClientOptions{})
 
 	// Setup zapsentry
 	core, err := zapsentry.NewCore(zapsentry.Configuration{
@@ -53,6 +50,7 @@ func ExampleAttachCoreToLogger() {
 		Error("[error] something went wrong!", zap.String("method", "unknown"), zapsentry.Tag("service", "app"))
 
 	// Check output
+	recordedSentryEvent := transport.LastEvent()
 	fmt.Println(recordedLogs.All()[0].Message)
 	fmt.Println(recordedSentryEvent.Message)
 	fmt.Println(recordedSentryEvent.Contexts["Extra"])
@@ -62,35 +60,3 @@ func ExampleAttachCoreToLogger() {
 	// map[method:unknown]
 	// map[component:system service:app]
 }
-
-func mockSentryClient(f func(event *sentry.Event)) *sentry.Client {
-	client, _ := sentry.NewClient(sentry.ClientOptions{
-		Dsn:       "",
-		Transport: &transport{MockSendEvent: f},
-	})
-	return client
-}
-
-type transport struct {
-	MockSendEvent func(event *sentry.Event)
-}
-
-// Flush waits until any buffered events are sent to the Sentry server, blocking
-// for at most the given timeout. It returns false if the timeout was reached.
-func (f *transport) Flush(_ time.Duration) bool { return true }
-
-// FlushWithContext waits until any buffered events are sent to the Sentry server,
-// blocking for at most the given context's deadline.
-func (f *transport) FlushWithContext(_ context.Context) bool { return true }
-
-// Configure is called by the Client itself, providing it it's own ClientOptions.
-func (f *transport) Configure(_ sentry.ClientOptions) {}
-
-// SendEvent assembles a new packet out of Event and sends it to remote server.
-// We use this method to capture the event for testing
-func (f *transport) SendEvent(event *sentry.Event) {
-	f.MockSendEvent(event)
-}
-
-// Close releases any resources held by the transport.
-func (f *transport) Close() {}
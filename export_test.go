@@ -0,0 +1,6 @@
+package zapsentry
+
+// ResetProfilesRateLimit lets tests attach profiles regardless of the ones attached by earlier tests.
+func ResetProfilesRateLimit() {
+	lastProfilesTime.Store(0)
+}
@@ -4,18 +4,36 @@ import (
 	"github.com/getsentry/sentry-go"
 )
 
-func NewSentryClientFromDSN(DSN string) SentryClientFactory {
+// NewSentryClientFromOptions creates a new client owned by the core,
+// so that Closer.Close closes it.
+func NewSentryClientFromOptions(options sentry.ClientOptions) OwnedSentryClientFactory {
 	return func() (*sentry.Client, error) {
-		return sentry.NewClient(sentry.ClientOptions{
-			Dsn: DSN,
-		})
+		return sentry.NewClient(options)
 	}
 }
 
+// NewSentryClientFromDSN creates a new client owned by the core, see NewSentryClientFromOptions.
+func NewSentryClientFromDSN(DSN string) OwnedSentryClientFactory {
+	return NewSentryClientFromOptions(sentry.ClientOptions{
+		Dsn: DSN,
+	})
+}
+
+// NewSentryClientFromClient reuses the client. Closer.Close never closes it.
 func NewSentryClientFromClient(client *sentry.Client) SentryClientFactory {
 	return func() (*sentry.Client, error) {
 		return client, nil
 	}
 }
 
+// SentryClientFactory returns a client the core doesn't own.
 type SentryClientFactory func() (*sentry.Client, error)
+
+// OwnedSentryClientFactory returns a client created just for the core,
+// which closes it on Closer.Close and when NewCore fails.
+type OwnedSentryClientFactory func() (*sentry.Client, error)
+
+// ClientFactory is satisfied by SentryClientFactory, OwnedSentryClientFactory and plain functions returning a client.
+type ClientFactory interface {
+	~func() (*sentry.Client, error)
+}
@@ -2,6 +2,8 @@ package zapsentry
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -16,14 +18,106 @@ func Tag(key string, value string) zap.Field {
 	return zap.Field{Key: key, Type: zapcore.SkipType, Interface: tagField{key, value}}
 }
 
+// TagInt adds a tag with the decimal representation of the value.
+func TagInt(key string, value int) zap.Field {
+	return Tag(key, strconv.Itoa(value))
+}
+
+// TagBool adds a tag with either "true" or "false" value.
+func TagBool(key string, value bool) zap.Field {
+	return Tag(key, strconv.FormatBool(value))
+}
+
+type stringerTagField struct {
+	Key   string
+	Value fmt.Stringer
+}
+
+// TagStringer adds a tag with the value of the String method, called only when an event is written.
+func TagStringer(key string, value fmt.Stringer) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.SkipType, Interface: stringerTagField{key, value}}
+}
+
+type tagsField map[string]string
+
+// Tags adds all the tags at once.
+func Tags(tags map[string]string) zap.Field {
+	return zap.Field{Key: "tags", Type: zapcore.SkipType, Interface: tagsField(tags)}
+}
+
+type contextTagField struct {
+	Key   string
+	Value func(ctx context.Context) string
+}
+
+// TagFromContext adds a tag with the value resolved from the context passed with Context, when an event is written.
+// The tag is skipped if there is no context or the value is empty.
+func TagFromContext(key string, value func(ctx context.Context) string) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.SkipType, Interface: contextTagField{key, value}}
+}
+
 type ctxField struct {
 	Value context.Context
 }
 
-// Context adds a context to the logger.
+// Context adds a context to the logger, either with With or to a single entry.
 // This can be used e.g. to pass trace information to sentry and allow linking events to their respective traces.
 //
 // See also https://docs.sentry.io/platforms/go/performance/instrumentation/opentelemetry/#linking-errors-to-transactions
 func Context(ctx context.Context) zap.Field {
 	return zap.Field{Key: "context", Type: zapcore.SkipType, Interface: ctxField{ctx}}
 }
+
+type attachmentField struct {
+	Filename    string
+	ContentType string
+	Data        func() ([]byte, error)
+}
+
+// Attachment attaches the data as a file to the event of the entry; it's ignored by With.
+// Data exceeding Configuration.MaxAttachmentSize is skipped with ErrAttachmentTooLarge.
+func Attachment(filename, contentType string, data []byte) zap.Field {
+	return AttachmentFunc(filename, contentType, func() ([]byte, error) { return data, nil })
+}
+
+// AttachmentFunc is like Attachment, but the data is returned by the function,
+// called only when an event is written.
+func AttachmentFunc(filename, contentType string, data func() ([]byte, error)) zap.Field {
+	return zap.Field{Key: filename, Type: zapcore.SkipType, Interface: attachmentField{filename, contentType, data}}
+}
+
+type recoveredPanic struct {
+	err error
+}
+
+func (p *recoveredPanic) Error() string {
+	return p.err.Error()
+}
+
+type panicValue struct {
+	Value interface{}
+}
+
+func (p panicValue) Error() string {
+	return fmt.Sprint(p.Value)
+}
+
+func (p panicValue) TypeName() string {
+	return "panic"
+}
+
+// Recovered adds a value returned by recover as an error under the "panic" key.
+// Sentry marks its exceptions as unhandled.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			logger.Error("recovered from panic", zapsentry.Recovered(r))
+//		}
+//	}()
+func Recovered(r interface{}) zap.Field {
+	err, ok := r.(error)
+	if !ok {
+		err = panicValue{r}
+	}
+	return zap.NamedError("panic", &recoveredPanic{err})
+}
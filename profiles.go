@@ -0,0 +1,64 @@
+package zapsentry
+
+import (
+	"fmt"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	defaultProfilesInterval   = time.Minute
+	profileAttachmentMIMEType = "application/octet-stream"
+)
+
+var (
+	// attachedProfiles are the pprof profiles attached to events.
+	attachedProfiles = []string{"goroutine", "heap"}
+
+	// lastProfilesTime is the time profiles were attached last by any core of the process, in Unix nanoseconds.
+	// It's shared by all cores, so that e.g. tee'd cores don't attach profiles of the same crash several times.
+	lastProfilesTime atomic.Int64
+)
+
+// profileAttachments returns the pprof profiles to attach to events of Panic and Fatal entries,
+// if Configuration.AttachProfiles is set and no profiles were attached by any core of the process
+// during Configuration.ProfilesInterval.
+func (c *core) profileAttachments() ([]*sentry.Attachment, []error) {
+	if !c.cfg.AttachProfiles {
+		return nil, nil
+	}
+
+	now := time.Now()
+	last := lastProfilesTime.Load()
+	if last != 0 && now.Sub(time.Unix(0, last)) < c.cfg.ProfilesInterval {
+		return nil, nil
+	}
+	if !lastProfilesTime.CompareAndSwap(last, now.UnixNano()) {
+		// another core is attaching profiles at the moment.
+		return nil, nil
+	}
+
+	var (
+		attachments []*sentry.Attachment
+		errs        []error
+	)
+
+	for _, name := range attachedProfiles {
+		buf := limitedBuffer{limit: c.cfg.MaxAttachmentSize}
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			errs = append(errs, fmt.Errorf("write %s profile: %w", name, err))
+			continue
+		}
+
+		attachments = append(attachments, &sentry.Attachment{
+			Filename:    name + ".pb.gz",
+			ContentType: profileAttachmentMIMEType,
+			Payload:     buf.Bytes(),
+		})
+	}
+
+	return attachments, errs
+}
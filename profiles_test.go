@@ -0,0 +1,70 @@
+package zapsentry_test
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func logPanic(logger *zap.Logger, msg string) {
+	defer func() { _ = recover() }()
+	logger.Panic(msg)
+}
+
+func TestAttachProfiles(t *testing.T) {
+	zapsentry.ResetProfilesRateLimit()
+	t.Cleanup(zapsentry.ResetProfilesRateLimit)
+
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:          zapcore.ErrorLevel,
+		AttachProfiles: true,
+	})
+
+	logger.DPanic("not crashing")
+	if attachments := transport.LastEvent().Attachments; len(attachments) != 0 {
+		t.Errorf("expected no profiles for DPanic, got %d", len(attachments))
+	}
+
+	logPanic(logger, "crashing")
+
+	attachments := transport.LastEvent().Attachments
+	if len(attachments) != 2 {
+		t.Fatalf("expected 2 profiles, got %d", len(attachments))
+	}
+	for i, filename := range []string{"goroutine.pb.gz", "heap.pb.gz"} {
+		if attachments[i].Filename != filename || len(attachments[i].Payload) == 0 {
+			t.Errorf("unexpected attachment %q of %d bytes", attachments[i].Filename, len(attachments[i].Payload))
+		}
+	}
+
+	logPanic(logger, "crashing again")
+	if attachments := transport.LastEvent().Attachments; len(attachments) != 0 {
+		t.Errorf("expected profiles to be rate limited, got %d", len(attachments))
+	}
+}
+
+func TestAttachProfilesAcrossCores(t *testing.T) {
+	zapsentry.ResetProfilesRateLimit()
+	t.Cleanup(zapsentry.ResetProfilesRateLimit)
+
+	cfg := zapsentry.Configuration{
+		Level:          zapcore.ErrorLevel,
+		AttachProfiles: true,
+	}
+	first, firstTransport := zapsentrytest.NewLogger(t, cfg)
+	second, secondTransport := zapsentrytest.NewLogger(t, cfg)
+
+	logPanic(first, "crashing")
+	logPanic(second, "crashing")
+
+	if attachments := firstTransport.LastEvent().Attachments; len(attachments) != 2 {
+		t.Errorf("expected 2 profiles from the first core, got %d", len(attachments))
+	}
+	if attachments := secondTransport.LastEvent().Attachments; len(attachments) != 0 {
+		t.Errorf("expected profiles to be rate limited across cores, got %d", len(attachments))
+	}
+}
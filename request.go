@@ -0,0 +1,134 @@
+package zapsentry
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRequestHeaderDenylist is used when Configuration.RequestHeaderDenylist is nil.
+var DefaultRequestHeaderDenylist = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Forwarded-For",
+	"X-Real-Ip",
+}
+
+type requestField struct {
+	Value *http.Request
+}
+
+// Request adds the HTTP request to sentry.Event(s), either with With or to a single entry.
+// Sentry shows it specially and allows to search events by its attributes.
+// The request context is used like the one of Context, unless the latter is also passed.
+func Request(r *http.Request) zap.Field {
+	return zap.Field{Key: "request", Type: zapcore.SkipType, Interface: requestField{r}}
+}
+
+type requestBodyField struct {
+	Value []byte
+}
+
+// RequestBody adds the body of the request passed with Request, either with With or to a single entry.
+// It's needed for server requests, whose bodies can't be read again; see Configuration.MaxRequestBodySize.
+func RequestBody(body []byte) zap.Field {
+	return zap.Field{Key: "request_body", Type: zapcore.SkipType, Interface: requestBodyField{body}}
+}
+
+// newRequest converts the request the way sentry.NewRequest does,
+// skipping headers of Configuration.RequestHeaderDenylist rather than the ones sentry-go deems sensitive, and
+// adding up to Configuration.MaxRequestBodySize bytes of the body.
+func (c *core) newRequest(r *http.Request) (*sentry.Request, error) {
+	denylist := c.cfg.RequestHeaderDenylist
+	if denylist == nil {
+		denylist = DefaultRequestHeaderDenylist
+	}
+
+	denied := func(key string) bool {
+		for _, k := range denylist {
+			if strings.EqualFold(k, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// client requests and proxy requests have absolute URLs, server ones only the path.
+	scheme, host := r.URL.Scheme, r.URL.Host
+	if !r.URL.IsAbs() {
+		scheme, host = "http", r.Host
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+	}
+
+	request := &sentry.Request{
+		URL:         fmt.Sprintf("%s://%s%s", scheme, host, r.URL.Path),
+		Method:      r.Method,
+		QueryString: r.URL.RawQuery,
+		Headers:     make(map[string]string, len(r.Header)+1),
+	}
+
+	for k, v := range r.Header {
+		if !denied(k) {
+			request.Headers[k] = strings.Join(v, ",")
+		}
+	}
+	request.Headers["Host"] = r.Host
+
+	// cookies and the peer address are personal data, like in sentry.NewRequest.
+	if c.client.Options().SendDefaultPII {
+		if !denied("Cookie") {
+			request.Cookies = r.Header.Get("Cookie")
+		}
+
+		if addr, port, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			request.Env = map[string]string{"REMOTE_ADDR": addr, "REMOTE_PORT": port}
+		}
+	}
+
+	data, err := c.requestBody(r)
+	if err != nil {
+		return request, fmt.Errorf("read request body: %w", err)
+	}
+	request.Data = data
+
+	return request, nil
+}
+
+// requestBody returns up to Configuration.MaxRequestBodySize bytes of the body passed with RequestBody
+// or else of the one got anew with http.Request.GetBody. The request body itself is never read,
+// as the request handler owns it.
+func (c *core) requestBody(r *http.Request) (string, error) {
+	if c.cfg.MaxRequestBodySize <= 0 {
+		return "", nil
+	}
+
+	if c.requestBodyData != nil {
+		return string(c.requestBodyData[:min(len(c.requestBodyData), c.cfg.MaxRequestBodySize)]), nil
+	}
+
+	if r.GetBody == nil {
+		return "", nil
+	}
+
+	body, err := r.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, int64(c.cfg.MaxRequestBodySize)))
+	return string(data), err
+}
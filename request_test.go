@@ -0,0 +1,152 @@
+package zapsentry_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func TestRequest(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:              zapcore.ErrorLevel,
+		MaxRequestBodySize: 5,
+	})
+
+	r := httptest.NewRequest("POST", "http://example.com/orders?id=7", strings.NewReader("payload"))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("Content-Type", "text/plain")
+
+	logger.With(zapsentry.Request(r), zapsentry.RequestBody([]byte("payload"))).Error("failed")
+
+	request := transport.LastEvent().Request
+	if request == nil {
+		t.Fatal("expected request")
+	}
+	if request.URL != "http://example.com/orders" || request.Method != "POST" || request.QueryString != "id=7" {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if want := map[string]string{"Content-Type": "text/plain", "Host": "example.com"}; !reflect.DeepEqual(request.Headers, want) {
+		t.Errorf("expected headers %v, got %v", want, request.Headers)
+	}
+	if request.Cookies != "" {
+		t.Errorf("unexpected cookies %q", request.Cookies)
+	}
+	if request.Data != "paylo" {
+		t.Errorf("expected capped body, got %q", request.Data)
+	}
+
+	if body, _ := io.ReadAll(r.Body); string(body) != "payload" {
+		t.Errorf("expected the body to remain unread, got %q", body)
+	}
+}
+
+func TestRequestGetBody(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:              zapcore.ErrorLevel,
+		MaxRequestBodySize: 5,
+	})
+
+	client, err := http.NewRequest("PUT", "https://api.example.com/v1/orders?id=7", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewRequest("POST", "http://example.com/orders", strings.NewReader("payload"))
+
+	logger.Error("client", zapsentry.Request(client))
+	logger.Error("server", zapsentry.Request(server))
+
+	if request := transport.FindEvent("client").Request; request.URL != "https://api.example.com/v1/orders" || request.QueryString != "id=7" {
+		t.Errorf("expected the URL of the client request, got %q", request.URL)
+	}
+	if data := transport.FindEvent("client").Request.Data; data != "paylo" {
+		t.Errorf("expected capped body of the client request, got %q", data)
+	}
+	if data := transport.FindEvent("server").Request.Data; data != "" {
+		t.Errorf("expected no body of the server request, got %q", data)
+	}
+	if body, _ := io.ReadAll(server.Body); string(body) != "payload" {
+		t.Errorf("expected the body to remain unread, got %q", body)
+	}
+}
+
+func TestRequestHeaderDenylist(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		pii         bool
+		wantCookies string
+		wantAddr    string
+	}{
+		{name: "without PII"},
+		{name: "with PII", pii: true, wantCookies: "session=1", wantAddr: "192.0.2.1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := zapsentrytest.NewTransport()
+			client := zapsentrytest.NewClient(transport, sentry.ClientOptions{SendDefaultPII: tt.pii})
+			core, err := zapsentry.NewCore(zapsentry.Configuration{
+				Level:                 zapcore.ErrorLevel,
+				RequestHeaderDenylist: []string{"x-internal"},
+			}, zapsentry.NewSentryClientFromClient(client))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest("GET", "https://example.com/", strings.NewReader("payload"))
+			r.Header.Set("Cookie", "session=1")
+			r.Header.Set("X-Internal", "1")
+
+			zap.New(core).Error("failed", zapsentry.Request(r))
+
+			request := transport.LastEvent().Request
+			if want := map[string]string{"Cookie": "session=1", "Host": "example.com"}; !reflect.DeepEqual(request.Headers, want) {
+				t.Errorf("expected headers %v, got %v", want, request.Headers)
+			}
+			if request.Cookies != tt.wantCookies || request.Data != "" {
+				t.Errorf("unexpected cookies %q or body %q", request.Cookies, request.Data)
+			}
+			if request.Env["REMOTE_ADDR"] != tt.wantAddr {
+				t.Errorf("unexpected env %v", request.Env)
+			}
+		})
+	}
+}
+
+func TestRequestConcurrent(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:              zapcore.ErrorLevel,
+		MaxRequestBodySize: 5,
+	})
+
+	r, err := http.NewRequest("POST", "http://example.com/orders", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger = logger.With(zapsentry.Request(r))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Error("failed")
+		}()
+	}
+	wg.Wait()
+
+	for _, event := range transport.Events() {
+		if event.Request.Data != "paylo" {
+			t.Errorf("expected capped body, got %q", event.Request.Data)
+		}
+	}
+}
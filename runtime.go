@@ -0,0 +1,138 @@
+package zapsentry
+
+import (
+	"fmt"
+	"maps"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	runtimeContextKey = "runtime"
+	buildContextKey   = "build"
+
+	modulesIntegrationName = "Modules"
+)
+
+// buildInfo is computed once, as it never changes during the process lifetime.
+// Both values are shared by all events, so they must be copied before adding them.
+var buildInfo = sync.OnceValues(func() (sentry.Context, map[string]string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, nil
+	}
+
+	build := sentry.Context{
+		"go_version":   info.GoVersion,
+		"path":         info.Path,
+		"main_module":  info.Main.Path,
+		"main_version": info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs", "vcs.revision", "vcs.time":
+			build[strings.ReplaceAll(setting.Key, ".", "_")] = setting.Value
+		case "vcs.modified":
+			build["vcs_dirty"], _ = strconv.ParseBool(setting.Value)
+		}
+	}
+
+	// the same format as the one of the sentry-go Modules integration.
+	modules := map[string]string{info.Main.Path: info.Main.Version}
+	for _, dep := range info.Deps {
+		version := dep.Version
+		if dep.Replace != nil {
+			version += fmt.Sprintf(" => %s %s", dep.Replace.Path, dep.Replace.Version)
+		}
+		modules[dep.Path] = strings.TrimSuffix(version, " ")
+	}
+
+	return build, modules
+})
+
+// memoryMetrics are the runtime/metrics reported under "go_memstats" by their keys,
+// read without stopping the world unlike runtime.ReadMemStats.
+var memoryMetrics = []struct {
+	key, name string
+}{
+	{"heap_alloc", "/memory/classes/heap/objects:bytes"},
+	{"heap_objects", "/gc/heap/objects:objects"},
+	{"stack_inuse", "/memory/classes/heap/stacks:bytes"},
+	{"sys", "/memory/classes/total:bytes"},
+	{"next_gc", "/gc/heap/goal:bytes"},
+	{"num_gc", "/gc/cycles/total:gc-cycles"},
+}
+
+// addRuntimeInfo adds the "runtime" and "build" contexts to the event, as well as modules,
+// if the client doesn't add them by itself, unless Configuration.DisableRuntimeContext is set.
+func (c *core) addRuntimeInfo(event *sentry.Event) {
+	if c.cfg.DisableRuntimeContext {
+		return
+	}
+
+	samples := make([]metrics.Sample, len(memoryMetrics))
+	for i, m := range memoryMetrics {
+		samples[i].Name = m.name
+	}
+	metrics.Read(samples)
+
+	memStats := make(map[string]interface{}, len(samples))
+	for i, sample := range samples {
+		if sample.Value.Kind() == metrics.KindUint64 {
+			memStats[memoryMetrics[i].key] = sample.Value.Uint64()
+		}
+	}
+
+	event.Contexts[runtimeContextKey] = sentry.Context{
+		"name":           "go",
+		"version":        runtime.Version(),
+		"go_os":          runtime.GOOS,
+		"go_arch":        runtime.GOARCH,
+		"go_maxprocs":    runtime.GOMAXPROCS(0),
+		"go_numroutines": runtime.NumGoroutine(),
+		"go_numcgocalls": runtime.NumCgoCall(),
+		"go_memstats":    memStats,
+	}
+
+	// don't let event processors modify the values shared by all events.
+	build, modules := buildInfo()
+	if build != nil {
+		event.Contexts[buildContextKey] = maps.Clone(build)
+	}
+	// the sentry-go Modules integration replaces modules of events anyway.
+	if !c.state.modulesIntegration && modules != nil {
+		event.Modules = maps.Clone(modules)
+	}
+}
+
+// hasModulesIntegration reports whether the client adds modules to events by itself.
+func hasModulesIntegration(client *sentry.Client) bool {
+	filter := client.Options().Integrations
+	if filter == nil {
+		// the Modules integration is one of the defaults.
+		return true
+	}
+
+	for _, integration := range filter([]sentry.Integration{modulesIntegrationProbe{}}) {
+		if integration.Name() == modulesIntegrationName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// modulesIntegrationProbe tells if ClientOptions.Integrations keep the Modules integration.
+type modulesIntegrationProbe struct{}
+
+func (modulesIntegrationProbe) Name() string {
+	return modulesIntegrationName
+}
+
+func (modulesIntegrationProbe) SetupOnce(*sentry.Client) {}
@@ -0,0 +1,86 @@
+package zapsentry_test
+
+import (
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func TestRuntimeContext(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		integrations func([]sentry.Integration) []sentry.Integration
+	}{
+		{name: "default integrations"},
+		{name: "no integrations", integrations: func([]sentry.Integration) []sentry.Integration { return nil }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := zapsentrytest.NewTransport()
+			client := zapsentrytest.NewClient(transport, sentry.ClientOptions{Integrations: tt.integrations})
+			core, err := zapsentry.NewCore(zapsentry.Configuration{Level: zapcore.ErrorLevel}, zapsentry.NewSentryClientFromClient(client))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			zap.New(core).Error("failed")
+
+			event := transport.LastEvent()
+			runtimeContext := event.Contexts["runtime"]
+			if runtimeContext["name"] != "go" || runtimeContext["go_numroutines"] == nil {
+				t.Errorf("unexpected runtime context %v", runtimeContext)
+			}
+			memStats, ok := runtimeContext["go_memstats"].(map[string]interface{})
+			if !ok || memStats["heap_alloc"] == nil || memStats["num_gc"] == nil {
+				t.Errorf("expected memory stats, got %v", runtimeContext)
+			}
+			if build := event.Contexts["build"]; build["go_version"] == nil {
+				t.Errorf("unexpected build context %v", build)
+			}
+			if len(event.Modules) == 0 {
+				t.Errorf("expected modules")
+			}
+
+			// events must not share the build info, unlike the modules of the sentry-go integration.
+			event.Contexts["build"]["go_version"] = "modified"
+			event.Modules["github.com/getsentry/sentry-go"] = "modified"
+			zap.New(core).Error("failed again")
+
+			event = transport.LastEvent()
+			if build := event.Contexts["build"]; build["go_version"] == "modified" {
+				t.Errorf("expected build context of every event to be a copy")
+			}
+			if tt.integrations != nil && event.Modules["github.com/getsentry/sentry-go"] == "modified" {
+				t.Errorf("expected modules of every event to be a copy")
+			}
+		})
+	}
+}
+
+func TestDisableRuntimeContext(t *testing.T) {
+	transport := zapsentrytest.NewTransport()
+	client := zapsentrytest.NewClient(transport, sentry.ClientOptions{
+		Integrations: func([]sentry.Integration) []sentry.Integration { return nil },
+	})
+	core, err := zapsentry.NewCore(zapsentry.Configuration{
+		Level:                 zapcore.ErrorLevel,
+		DisableRuntimeContext: true,
+	}, zapsentry.NewSentryClientFromClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zap.New(core).Error("failed")
+
+	event := transport.LastEvent()
+	if _, ok := event.Contexts["build"]; ok {
+		t.Errorf("unexpected build context")
+	}
+	if len(event.Modules) != 0 {
+		t.Errorf("unexpected modules %v", event.Modules)
+	}
+}
@@ -1,10 +1,46 @@
 package zapsentry
 
 import (
+	"math"
+
 	"github.com/getsentry/sentry-go"
 	"go.uber.org/zap/zapcore"
 )
 
+type (
+	SeverityMapperFunc func(lvl zapcore.Level) sentry.Level
+	// SeverityMap maps the listed levels explicitly and falls back
+	// to the default mapping for the rest.
+	SeverityMap map[zapcore.Level]sentry.Level
+)
+
+// SeverityMapper converts zap levels, including custom ones, to Sentry severities.
+type SeverityMapper interface {
+	Severity(lvl zapcore.Level) sentry.Level
+}
+
+const (
+	minCustomLevel = zapcore.Level(math.MinInt8)
+	maxCustomLevel = zapcore.Level(math.MaxInt8)
+)
+
+var (
+	// DefaultSeverityMapper is used when Configuration.SeverityMapper is not set.
+	// Custom levels below Debug are reported as debug, the ones above Fatal as fatal.
+	DefaultSeverityMapper SeverityMapper = SeverityMapperFunc(sentrySeverity)
+)
+
+func (f SeverityMapperFunc) Severity(lvl zapcore.Level) sentry.Level {
+	return f(lvl)
+}
+
+func (m SeverityMap) Severity(lvl zapcore.Level) sentry.Level {
+	if severity, ok := m[lvl]; ok {
+		return severity
+	}
+	return sentrySeverity(lvl)
+}
+
 func sentrySeverity(lvl zapcore.Level) sentry.Level {
 	switch lvl {
 	case zapcore.DebugLevel:
@@ -21,8 +57,29 @@ func sentrySeverity(lvl zapcore.Level) sentry.Level {
 		return sentry.LevelFatal
 	case zapcore.FatalLevel:
 		return sentry.LevelFatal
-	default:
-		// Unrecognized levels are fatal.
-		return sentry.LevelFatal
+	}
+
+	// Custom levels are clamped to the range of zap levels.
+	if lvl < zapcore.DebugLevel {
+		return sentry.LevelDebug
+	}
+	return sentry.LevelFatal
+}
+
+// isCustomLevel reports whether lvl lies outside the range of levels zap defines.
+func isCustomLevel(lvl zapcore.Level) bool {
+	return lvl < zapcore.DebugLevel || lvl > zapcore.FatalLevel
+}
+
+// minEnabledLevel is like zapcore.LevelOf, but also considers custom levels
+// outside the standard range. It returns false if no level is enabled.
+func minEnabledLevel(enab zapcore.LevelEnabler) (zapcore.Level, bool) {
+	for lvl := minCustomLevel; ; lvl++ {
+		if enab.Enabled(lvl) {
+			return lvl, true
+		}
+		if lvl == maxCustomLevel {
+			return lvl, false
+		}
 	}
 }
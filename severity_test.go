@@ -0,0 +1,85 @@
+package zapsentry_test
+
+import (
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+const traceLevel = zapcore.DebugLevel - 1
+
+func TestSeverityMapper(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:          zapcore.ErrorLevel,
+		SeverityMapper: zapsentry.SeverityMap{zapcore.DPanicLevel: sentry.LevelError},
+	})
+
+	logger.DPanic("dpanic")
+	if event := transport.LastEvent(); event == nil || event.Level != sentry.LevelError {
+		t.Errorf("expected DPanic to be reported as error, got %v", event)
+	}
+
+	logger.Warn("warn")
+	logger.Error("error")
+	transport.AssertEventCount(t, 2)
+	if event := transport.LastEvent(); event.Level != sentry.LevelError {
+		t.Errorf("expected error level, got %v", event.Level)
+	}
+}
+
+func TestCustomBreadcrumbLevel(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		EnableBreadcrumbs: true,
+		BreadcrumbLevel:   traceLevel,
+		LevelNames:        map[zapcore.Level]string{traceLevel: "trace"},
+	})
+
+	if ce := logger.Check(traceLevel, "trace"); ce != nil {
+		ce.Write(zap.String("key", "value"), zap.String("level", "field"))
+	} else {
+		t.Fatal("expected trace level to be enabled")
+	}
+	logger.Error("error")
+
+	breadcrumbs := transport.LastEvent().Breadcrumbs()
+	if len(breadcrumbs) != 2 {
+		t.Fatalf("expected exactly two breadcrumbs, got %v", breadcrumbs)
+	}
+	if breadcrumbs[0].Level != sentry.LevelDebug {
+		t.Errorf("expected debug breadcrumb, got %v", breadcrumbs[0].Level)
+	}
+	if data := breadcrumbs[0].Data; data["zap_level"] != "trace" || data["level"] != "field" || data["key"] != "value" {
+		t.Errorf("unexpected breadcrumb data %v", breadcrumbs[0].Data)
+	}
+}
+
+func TestDefaultSeverityOfCustomLevels(t *testing.T) {
+	for lvl, want := range map[zapcore.Level]sentry.Level{
+		traceLevel:             sentry.LevelDebug,
+		zapcore.Level(-100):    sentry.LevelDebug,
+		zapcore.FatalLevel + 1: sentry.LevelFatal,
+		zapcore.WarnLevel:      sentry.LevelWarning,
+		zapcore.DPanicLevel:    sentry.LevelFatal,
+	} {
+		if got := zapsentry.DefaultSeverityMapper.Severity(lvl); got != want {
+			t.Errorf("expected %v for level %d, got %v", want, lvl, got)
+		}
+	}
+}
+
+func TestSeverityMapAsDefault(t *testing.T) {
+	defaultMapper := zapsentry.DefaultSeverityMapper
+	defer func() { zapsentry.DefaultSeverityMapper = defaultMapper }()
+
+	zapsentry.DefaultSeverityMapper = zapsentry.SeverityMap{zapcore.DPanicLevel: sentry.LevelError}
+
+	if got := zapsentry.DefaultSeverityMapper.Severity(zapcore.InfoLevel); got != sentry.LevelInfo {
+		t.Errorf("expected info, got %v", got)
+	}
+}
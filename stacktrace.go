@@ -0,0 +1,238 @@
+package zapsentry
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type (
+	StacktraceExtractors    []StacktraceExtractor
+	StacktraceExtractorFunc func(err error) *sentry.Stacktrace
+)
+
+// StacktraceExtractor extracts the stack trace carried by a single error of a chain.
+// It returns nil if the error has no stack trace it recognizes.
+type StacktraceExtractor interface {
+	ExtractStacktrace(err error) *sentry.Stacktrace
+}
+
+var (
+	// CallersStacktraceExtractor supports errors having a Callers() []uintptr method.
+	CallersStacktraceExtractor = StacktraceExtractorFunc(func(err error) *sentry.Stacktrace {
+		if cast, ok := err.(interface{ Callers() []uintptr }); ok {
+			return stacktraceFromPCs(cast.Callers())
+		}
+		return nil
+	})
+
+	// PkgErrorsStacktraceExtractor supports github.com/pkg/errors and compatible errors
+	// having a StackTrace() method returning program counters.
+	// It's not one of the defaults, as SentryStacktraceExtractor supports such errors as well.
+	PkgErrorsStacktraceExtractor = StacktraceExtractorFunc(func(err error) *sentry.Stacktrace {
+		return stacktraceFromPCs(reflectedPCs(err, "StackTrace"))
+	})
+
+	// GoErrorsStacktraceExtractor supports github.com/go-errors/errors and compatible errors
+	// having a StackFrames() method returning frames with a ProgramCounter field.
+	// It's not one of the defaults, as SentryStacktraceExtractor supports such errors as well.
+	GoErrorsStacktraceExtractor = StacktraceExtractorFunc(func(err error) *sentry.Stacktrace {
+		return stacktraceFromPCs(reflectedPCs(err, "StackFrames"))
+	})
+
+	// SafeDetailsStacktraceExtractor supports github.com/cockroachdb/errors style errors
+	// having a SafeDetails() []string method, one of the details being a stack trace
+	// formatted like github.com/pkg/errors does with "%+v".
+	SafeDetailsStacktraceExtractor = StacktraceExtractorFunc(func(err error) *sentry.Stacktrace {
+		if cast, ok := err.(interface{ SafeDetails() []string }); ok {
+			for _, detail := range cast.SafeDetails() {
+				if stacktrace := parseStacktrace(detail); stacktrace != nil {
+					return stacktrace
+				}
+			}
+		}
+		return nil
+	})
+
+	// SentryStacktraceExtractor is sentry.ExtractStacktrace, which supports github.com/pkg/errors,
+	// github.com/go-errors/errors, github.com/pingcap/errors and golang.org/x/xerrors.
+	SentryStacktraceExtractor = StacktraceExtractorFunc(sentry.ExtractStacktrace)
+
+	// sentry-go comes first to keep the grouping of issues reported before custom extractors were supported.
+	defaultStacktraceExtractors = StacktraceExtractors{
+		SentryStacktraceExtractor,
+		CallersStacktraceExtractor,
+		SafeDetailsStacktraceExtractor,
+	}
+)
+
+func (f StacktraceExtractorFunc) ExtractStacktrace(err error) *sentry.Stacktrace {
+	return f(err)
+}
+
+// ExtractStacktrace returns the first stack trace found by the extractors.
+func (ee StacktraceExtractors) ExtractStacktrace(err error) *sentry.Stacktrace {
+	for i := range ee {
+		if stacktrace := ee[i].ExtractStacktrace(err); stacktrace != nil {
+			return stacktrace
+		}
+	}
+	return nil
+}
+
+func CombineStacktraceExtractors(extractor ...StacktraceExtractor) StacktraceExtractor {
+	return StacktraceExtractors(extractor)
+}
+
+// reflectedPCs calls the method, if the error has one, and collects program counters
+// from the returned slice of either uintptr kinds or structs with a ProgramCounter or PC field.
+func reflectedPCs(err error, methodName string) []uintptr {
+	method := reflect.ValueOf(err).MethodByName(methodName)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+
+	stacktrace := method.Call(nil)[0]
+	if stacktrace.Kind() != reflect.Slice {
+		return nil
+	}
+
+	pcs := make([]uintptr, 0, stacktrace.Len())
+	for i := 0; i < stacktrace.Len(); i++ {
+		pc := reflect.Indirect(stacktrace.Index(i))
+
+		switch pc.Kind() {
+		case reflect.Uintptr:
+			pcs = append(pcs, uintptr(pc.Uint()))
+		case reflect.Struct:
+			for _, fieldName := range []string{"ProgramCounter", "PC"} {
+				if field := pc.FieldByName(fieldName); field.IsValid() && field.Kind() == reflect.Uintptr {
+					pcs = append(pcs, uintptr(field.Uint()))
+					break
+				}
+			}
+		}
+	}
+
+	return pcs
+}
+
+// stacktraceFromPCs converts program counters, the innermost call first, to a stack trace
+// the way sentry.ExtractStacktrace does, so that the grouping of issues doesn't depend on the extractor.
+func stacktraceFromPCs(pcs []uintptr) *sentry.Stacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	frames := make([]sentry.Frame, 0, len(pcs))
+	callersFrames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callersFrames.Next()
+		if f := sentry.NewFrame(frame); !skipSentryFrame(f.Module) {
+			frames = append(frames, f)
+		}
+		if !more {
+			break
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil
+	}
+
+	// Sentry expects the innermost call to be the last one.
+	slices.Reverse(frames)
+
+	return &sentry.Stacktrace{Frames: cleanupFunctionNamePrefix(frames)}
+}
+
+// skipSentryFrame reports whether sentry-go drops frames of the module from stack traces.
+func skipSentryFrame(module string) bool {
+	if module == "runtime" || module == "testing" {
+		return true
+	}
+
+	return strings.HasPrefix(module, "github.com/getsentry/sentry-go") && !strings.HasSuffix(module, "_test")
+}
+
+// cleanupFunctionNamePrefix removes the names of the parent functions from the ones of closures,
+// the same way sentry-go does since Go 1.21 made function names fully qualified.
+func cleanupFunctionNamePrefix(frames []sentry.Frame) []sentry.Frame {
+	for i := len(frames) - 1; i > 0; i-- {
+		if name, ok := strings.CutPrefix(frames[i].Function, frames[i-1].Function+"."); ok {
+			frames[i].Function = name
+		}
+	}
+
+	return frames
+}
+
+// parseStacktrace parses stack traces formatted as
+//
+//	package.Function
+//		/path/to/file.go:42
+//
+// for every frame, the innermost call first, as well as the stacks of runtime.Stack.
+func parseStacktrace(s string) *sentry.Stacktrace {
+	var (
+		frames   []sentry.Frame
+		function string
+	)
+
+	for _, line := range strings.Split(s, "\n") {
+		if !strings.HasPrefix(line, "\t") {
+			function = stackFunctionName(strings.TrimSpace(line))
+			continue
+		}
+		if function == "" {
+			continue
+		}
+
+		// runtime.Stack follows locations with the PC offset, e.g. "file.go:12 +0x1d".
+		location, _, _ := strings.Cut(strings.TrimSpace(line), " +0x")
+		sep := strings.LastIndexByte(location, ':')
+		if sep < 0 {
+			continue
+		}
+		lineno, err := strconv.Atoi(location[sep+1:])
+		if err != nil {
+			continue
+		}
+
+		frames = append(frames, sentry.NewFrame(runtime.Frame{
+			Function: function,
+			File:     location[:sep],
+			Line:     lineno,
+		}))
+		function = ""
+	}
+
+	if len(frames) == 0 {
+		return nil
+	}
+
+	slices.Reverse(frames)
+
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// stackFunctionName trims the arguments and goroutine creation details
+// runtime.Stack adds to function names, e.g. "main.f(0x1, 0x2)" or "created by main.main in goroutine 1".
+func stackFunctionName(line string) string {
+	if name, ok := strings.CutPrefix(line, "created by "); ok {
+		name, _, _ = strings.Cut(name, " in goroutine ")
+		return name
+	}
+
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndexByte(line, '('); i > 0 {
+			return line[:i]
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,156 @@
+package zapsentry
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type pkgFrame uintptr
+
+type pkgError struct{ pcs []pkgFrame }
+
+func (e pkgError) Error() string          { return "pkg error" }
+func (e pkgError) StackTrace() []pkgFrame { return e.pcs }
+
+type goStackFrame struct{ ProgramCounter uintptr }
+
+type goError struct{ frames []goStackFrame }
+
+func (e goError) Error() string               { return "go error" }
+func (e goError) StackFrames() []goStackFrame { return e.frames }
+
+type callersError struct{ pcs []uintptr }
+
+func (e callersError) Error() string      { return "callers error" }
+func (e callersError) Callers() []uintptr { return e.pcs }
+
+type safeDetailsError struct{ details []string }
+
+func (e safeDetailsError) Error() string         { return "safe details error" }
+func (e safeDetailsError) SafeDetails() []string { return e.details }
+
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(1, pcs)]
+}
+
+func TestStacktraceExtractors(t *testing.T) {
+	t.Parallel()
+	pcs := callers()
+	pkgFrames := make([]pkgFrame, len(pcs))
+	goFrames := make([]goStackFrame, len(pcs))
+	for i, pc := range pcs {
+		pkgFrames[i] = pkgFrame(pc)
+		goFrames[i] = goStackFrame{pc}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "callers", err: callersError{pcs}},
+		{name: "pkg/errors", err: pkgError{pkgFrames}},
+		{name: "go-errors", err: goError{goFrames}},
+		{name: "safe details", err: safeDetailsError{[]string{
+			"some detail",
+			"github.com/TheZeroSlave/zapsentry.TestStacktraceExtractors\n\t/src/zapsentry/stacktrace_test.go:42\ntesting.tRunner\n\t/go/src/testing/testing.go:1690",
+		}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stacktrace := defaultStacktraceExtractors.ExtractStacktrace(fmt.Errorf("wrapped: %w", tt.err))
+			if stacktrace != nil {
+				t.Fatalf("expected wrapper to have no stack trace, got %v", stacktrace)
+			}
+
+			stacktrace = defaultStacktraceExtractors.ExtractStacktrace(tt.err)
+			if stacktrace == nil || len(stacktrace.Frames) == 0 {
+				t.Fatal("expected stack trace")
+			}
+			last := stacktrace.Frames[len(stacktrace.Frames)-1]
+			if last.Function != "TestStacktraceExtractors" && last.Function != "callers" {
+				t.Errorf("expected innermost frame to be the last one, got %+v", last)
+			}
+		})
+	}
+}
+
+func TestStacktraceFromPCsMatchesSentry(t *testing.T) {
+	pcs := callers()
+	pkgFrames := make([]pkgFrame, len(pcs))
+	for i, pc := range pcs {
+		pkgFrames[i] = pkgFrame(pc)
+	}
+	err := pkgError{pkgFrames}
+
+	got := PkgErrorsStacktraceExtractor.ExtractStacktrace(err)
+	want := sentry.ExtractStacktrace(err)
+	if got == nil || want == nil || len(got.Frames) != len(want.Frames) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	for i := range want.Frames {
+		if got.Frames[i].Module != want.Frames[i].Module || got.Frames[i].Function != want.Frames[i].Function {
+			t.Errorf("frame %d: expected %s.%s, got %s.%s", i,
+				want.Frames[i].Module, want.Frames[i].Function, got.Frames[i].Module, got.Frames[i].Function)
+		}
+		if module := got.Frames[i].Module; module == "runtime" || module == "testing" {
+			t.Errorf("expected %s frames to be skipped", module)
+		}
+	}
+}
+
+func TestCustomStacktraceExtractor(t *testing.T) {
+	t.Parallel()
+	custom := &sentry.Stacktrace{Frames: []sentry.Frame{{Function: "custom"}}}
+	c := &core{cfg: &Configuration{
+		FrameMatcher:       FrameMatchers{},
+		ExceptionTypeNamer: DefaultExceptionTypeNamer{},
+		MaxErrorDepth:      defaultMaxErrorDepth,
+		StacktraceExtractor: append(StacktraceExtractors{
+			StacktraceExtractorFunc(func(err error) *sentry.Stacktrace {
+				if err.Error() == "custom" {
+					return custom
+				}
+				return nil
+			}),
+		}, defaultStacktraceExtractors...),
+	}}
+
+	exceptions := c.addExceptionsFromError(nil, map[error]struct{}{}, errorField{
+		key: "error",
+		err: fmt.Errorf("wrapped: %w", errors.New("custom")),
+	}, true)
+	if len(exceptions) != 2 || exceptions[0].Stacktrace != nil || exceptions[1].Stacktrace != custom {
+		t.Errorf("expected custom stack trace for the wrapped error, got %+v", exceptions)
+	}
+}
+
+func TestParseGoroutineStack(t *testing.T) {
+	id, state, ok := parseGoroutineHeader("goroutine 5 [chan receive, 3 minutes]:")
+	if !ok || id != "5" || state != "chan receive, 3 minutes" {
+		t.Errorf("unexpected header %q %q %v", id, state, ok)
+	}
+
+	stacktrace := parseStacktrace("main.(*T).wait(0xc000010000, {0x1, 0x2})\n" +
+		"\t/app/main.go:12 +0x1d\n" +
+		"created by main.main in goroutine 1\n" +
+		"\t/app/main.go:30 +0x65")
+	if stacktrace == nil || len(stacktrace.Frames) != 2 {
+		t.Fatalf("unexpected stacktrace %+v", stacktrace)
+	}
+	for i, want := range []sentry.Frame{
+		{Module: "main", Function: "main", Lineno: 30},
+		{Module: "main", Function: "(*T).wait", Lineno: 12},
+	} {
+		got := stacktrace.Frames[i]
+		if got.Module != want.Module || got.Function != want.Function || got.Lineno != want.Lineno {
+			t.Errorf("frame %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package zapsentry
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatsGetter is implemented by the core to report how reporting to Sentry goes.
+type StatsGetter interface {
+	Stats() Stats
+}
+
+// Counts are the numbers of entries handled by the core.
+type Counts struct {
+	// Events is the number of events accepted by sentry.Client.CaptureEvent.
+	Events int64
+	// Dropped is the number of events rejected by sentry.Client.CaptureEvent,
+	// e.g. by sampling, event processors or BeforeSend.
+	// Events dropped later by the transport, e.g. on queue overflow or rate limiting, aren't counted,
+	// as sentry-go doesn't report them to the caller; they are counted in Events.
+	Dropped int64
+	// Breadcrumbs is the number of breadcrumbs added to scopes.
+	Breadcrumbs int64
+}
+
+// Stats is a snapshot of the core statistics, shared by the core and all its clones.
+type Stats struct {
+	Counts
+
+	// FlushTimeouts is the number of flushes which didn't complete in time.
+	FlushTimeouts int64
+	// Discarded is the number of writes after Close.
+	Discarded int64
+	// AverageBuildTime is the average time spent building a sentry.Event.
+	AverageBuildTime time.Duration
+
+	// ByLevel breaks Counts down by level name.
+	ByLevel map[string]Counts
+	// ByRoute breaks Counts down by logger name; the root logger is "".
+	// Only the first 100 names are kept, the counts of the others are added up under "<other>".
+	ByRoute map[string]Counts
+}
+
+const (
+	maxStatsRoutes  = 100
+	otherStatsRoute = "<other>"
+)
+
+// publishedStats are the stats published with expvar by cores, by variable name.
+// expvar variables can't be removed, so a core publishing under the name of another one replaces the target.
+var publishedStats = struct {
+	sync.Mutex
+	targets map[string]*atomic.Pointer[stats]
+}{
+	targets: make(map[string]*atomic.Pointer[stats]),
+}
+
+type stats struct {
+	mu sync.Mutex
+
+	Stats
+	built     int64
+	buildTime time.Duration
+}
+
+func newStats() *stats {
+	return &stats{
+		Stats: Stats{
+			ByLevel: make(map[string]Counts),
+			ByRoute: make(map[string]Counts),
+		},
+	}
+}
+
+func (s *stats) add(level, route string, delta Counts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Counts = s.Counts.add(delta)
+	s.ByLevel[level] = s.ByLevel[level].add(delta)
+	if _, ok := s.ByRoute[route]; !ok && len(s.ByRoute) >= maxStatsRoutes {
+		route = otherStatsRoute
+	}
+	s.ByRoute[route] = s.ByRoute[route].add(delta)
+}
+
+func (s *stats) addBuildTime(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.built++
+	s.buildTime += d
+}
+
+func (s *stats) addFlushTimeout() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.FlushTimeouts++
+}
+
+func (s *stats) addDiscarded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Discarded++
+}
+
+func (s *stats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.Stats
+	if s.built > 0 {
+		snapshot.AverageBuildTime = s.buildTime / time.Duration(s.built)
+	}
+
+	snapshot.ByLevel = make(map[string]Counts, len(s.ByLevel))
+	for k, v := range s.ByLevel {
+		snapshot.ByLevel[k] = v
+	}
+	snapshot.ByRoute = make(map[string]Counts, len(s.ByRoute))
+	for k, v := range s.ByRoute {
+		snapshot.ByRoute[k] = v
+	}
+
+	return snapshot
+}
+
+// publish exposes the statistics as an expvar variable,
+// replacing the statistics published under the name by another core.
+func (s *stats) publish(name string) error {
+	publishedStats.Lock()
+	defer publishedStats.Unlock()
+
+	if target, ok := publishedStats.targets[name]; ok {
+		target.Store(s)
+		return nil
+	}
+
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+
+	target := new(atomic.Pointer[stats])
+	target.Store(s)
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return target.Load().snapshot()
+	}))
+	publishedStats.targets[name] = target
+
+	return nil
+}
+
+func (c Counts) add(delta Counts) Counts {
+	return Counts{
+		Events:      c.Events + delta.Events,
+		Dropped:     c.Dropped + delta.Dropped,
+		Breadcrumbs: c.Breadcrumbs + delta.Breadcrumbs,
+	}
+}
@@ -0,0 +1,118 @@
+package zapsentry_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+// statsRun makes expvar names unique, as they can't be removed between runs of the tests.
+var statsRun atomic.Int64
+
+func TestStats(t *testing.T) {
+	expvarName := fmt.Sprintf("zapsentry_test_stats_%d", statsRun.Add(1))
+
+	transport := zapsentrytest.NewTransport()
+	client := zapsentrytest.NewClient(transport, sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			if event.Message == "dropped" {
+				return nil
+			}
+			return event
+		},
+	})
+	core, err := zapsentry.NewCore(zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		EnableBreadcrumbs: true,
+		BreadcrumbLevel:   zapcore.InfoLevel,
+		ExpvarName:        expvarName,
+		ErrorHandler:      func(error) {},
+	}, zapsentry.NewSentryClientFromClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zap.New(core).With(zapsentry.NewScope())
+
+	logger.Info("info")
+	logger.Named("db").Error("captured")
+	logger.Error("dropped")
+	transport.FailFlush(true)
+	_ = core.Sync()
+	transport.FailFlush(false)
+	_ = core.(zapsentry.Closer).Close(context.Background())
+	logger.Error("discarded")
+
+	stats := core.(zapsentry.StatsGetter).Stats()
+	want := zapsentry.Counts{Events: 1, Dropped: 1, Breadcrumbs: 3}
+	if stats.Counts != want {
+		t.Errorf("expected %+v, got %+v", want, stats.Counts)
+	}
+	if stats.FlushTimeouts != 1 || stats.Discarded != 1 {
+		t.Errorf("expected one flush timeout and one discarded write, got %+v", stats)
+	}
+	if got := stats.ByLevel["error"]; got != (zapsentry.Counts{Events: 1, Dropped: 1, Breadcrumbs: 2}) {
+		t.Errorf("unexpected error level counts %+v", got)
+	}
+	if got := stats.ByRoute["db"]; got != (zapsentry.Counts{Events: 1, Breadcrumbs: 1}) {
+		t.Errorf("unexpected db route counts %+v", got)
+	}
+	if stats.AverageBuildTime <= 0 {
+		t.Errorf("expected positive build time, got %v", stats.AverageBuildTime)
+	}
+
+	published := func() zapsentry.Stats {
+		var published zapsentry.Stats
+		if err := json.Unmarshal([]byte(expvar.Get(expvarName).String()), &published); err != nil {
+			t.Fatal(err)
+		}
+		return published
+	}
+	if got := published().Counts; got != want {
+		t.Errorf("expected published %+v, got %+v", want, got)
+	}
+
+	// a core made on restart takes the name over.
+	_, err = zapsentry.NewCore(
+		zapsentry.Configuration{Level: zapcore.ErrorLevel, ExpvarName: expvarName},
+		zapsentry.NewSentryClientFromClient(client),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := published().Counts; got != (zapsentry.Counts{}) {
+		t.Errorf("expected stats of the new core, got %+v", got)
+	}
+
+	expvar.NewInt(expvarName + "_int")
+	_, err = zapsentry.NewCore(
+		zapsentry.Configuration{Level: zapcore.ErrorLevel, ExpvarName: expvarName + "_int"},
+		zapsentry.NewSentryClientFromClient(client),
+	)
+	if err == nil {
+		t.Error("expected error for expvar name taken by another variable")
+	}
+}
+
+func TestStatsRoutesLimit(t *testing.T) {
+	core, _ := zapsentrytest.NewCore(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+	logger := zap.New(core)
+
+	for i := 0; i < 150; i++ {
+		logger.Named(fmt.Sprintf("route%d", i)).Error("captured")
+	}
+
+	stats := core.(zapsentry.StatsGetter).Stats()
+	if len(stats.ByRoute) != 101 || stats.ByRoute["<other>"].Events != 50 {
+		t.Errorf("expected 100 routes and 50 other events, got %d routes and %+v", len(stats.ByRoute), stats.ByRoute["<other>"])
+	}
+}
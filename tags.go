@@ -0,0 +1,177 @@
+package zapsentry
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	maxTagKeyLength   = 32
+	maxTagValueLength = 200
+
+	invalidTagsContextKey = "invalid_tags"
+)
+
+var (
+	// ErrInvalidTag is passed to Configuration.ErrorHandler for tags exceeding Sentry limits
+	// or containing characters Sentry rejects.
+	ErrInvalidTag = errors.New("invalid sentry tag")
+
+	newlineReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+)
+
+// TagPolicy defines what happens to tags exceeding Sentry limits of
+// 32 characters for keys and 200 characters for values.
+// Whatever the policy, changed tags colliding with other tags are moved to the "invalid_tags" context.
+type TagPolicy int
+
+const (
+	// TagPolicyTruncate cuts keys and values to the limits.
+	TagPolicyTruncate TagPolicy = iota
+	// TagPolicyHashSuffix cuts keys and values to the limits, replacing their ends
+	// with a hash of the whole string, so that distinct long values remain distinct.
+	TagPolicyHashSuffix
+	// TagPolicyContext moves such tags to the "invalid_tags" context.
+	TagPolicyContext
+)
+
+type stringTag string
+
+func (s stringTag) String() string {
+	return string(s)
+}
+
+// addTags adds the tags of Tag and similar fields to the event.
+func (c *core) addTags(event *sentry.Event) {
+	for k, v := range c.tags {
+		event.Tags[k] = stringOf(v)
+	}
+
+	ctx := c.context()
+	if ctx == nil {
+		return
+	}
+	for _, tag := range c.contextTags {
+		if value := tag.Value(ctx); value != "" {
+			event.Tags[tag.Key] = value
+		}
+	}
+}
+
+// stringOf calls String of possibly nil fmt.Stringer(s) the way zap.Stringer does.
+func stringOf(s fmt.Stringer) string {
+	if v := reflect.ValueOf(s); s == nil || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return "<nil>"
+	}
+
+	return s.String()
+}
+
+// sanitizeTags makes the event tags acceptable for Sentry according to Configuration.TagPolicy.
+// Newlines are replaced with spaces and characters Sentry doesn't allow in keys with underscores.
+// Colliding tags are moved to the context, so that no value is lost whatever the order of the tags.
+// It returns an error for every tag it had to change.
+func (c *core) sanitizeTags(event *sentry.Event) []error {
+	type changedTag struct {
+		originalKey, key, value string
+	}
+
+	var (
+		errs    []error
+		invalid sentry.Context
+		changed []changedTag
+	)
+
+	moveToContext := func(key, value string) {
+		if invalid == nil {
+			invalid = make(sentry.Context)
+		}
+		invalid[key] = value
+	}
+
+	// the tags are collected anew, as changed keys mustn't be revisited.
+	tags := make(map[string]string, len(event.Tags))
+	keys := make(map[string]int, len(event.Tags))
+
+	for key, value := range event.Tags {
+		newKey, newValue := sanitizeTagKey(key), newlineReplacer.Replace(value)
+		oversized := utf8.RuneCountInString(newKey) > maxTagKeyLength || utf8.RuneCountInString(newValue) > maxTagValueLength
+
+		if newKey == key && newValue == value && !oversized {
+			tags[key] = value
+			keys[key]++
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("%w: %q", ErrInvalidTag, key))
+
+		switch {
+		case oversized && c.cfg.TagPolicy == TagPolicyContext:
+			moveToContext(key, value)
+			continue
+		case oversized && c.cfg.TagPolicy == TagPolicyHashSuffix:
+			newKey, newValue = hashSuffix(newKey, maxTagKeyLength), hashSuffix(newValue, maxTagValueLength)
+		default:
+			newKey, newValue = truncate(newKey, maxTagKeyLength), truncate(newValue, maxTagValueLength)
+		}
+
+		changed = append(changed, changedTag{originalKey: key, key: newKey, value: newValue})
+		keys[newKey]++
+	}
+
+	for _, tag := range changed {
+		if keys[tag.key] > 1 {
+			moveToContext(tag.originalKey, event.Tags[tag.originalKey])
+			continue
+		}
+
+		tags[tag.key] = tag.value
+	}
+
+	event.Tags = tags
+	if invalid != nil {
+		event.Contexts[invalidTagsContextKey] = invalid
+	}
+
+	return errs
+}
+
+// sanitizeTagKey replaces characters other than alphanumerics and "_.:-" with underscores.
+func sanitizeTagKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '_', r == '.', r == ':', r == '-':
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	return string([]rune(s)[:limit])
+}
+
+// hashSuffix truncates s, replacing its end with "-" and 8 hex digits of its hash,
+// "-" being allowed in tag keys.
+func hashSuffix(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+
+	return fmt.Sprintf("%s-%08x", string([]rune(s)[:limit-9]), h.Sum32())
+}
@@ -0,0 +1,187 @@
+package zapsentry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func fnvSuffix(s string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return fmt.Sprintf("-%08x", h.Sum32())
+}
+
+func TestTagPolicy(t *testing.T) {
+	longKey := strings.Repeat("k", 40)
+	longValue := strings.Repeat("v", 250)
+
+	for _, tt := range []struct {
+		name        string
+		policy      zapsentry.TagPolicy
+		wantTags    map[string]string
+		wantContext bool
+	}{
+		{
+			name:   "truncate",
+			policy: zapsentry.TagPolicyTruncate,
+			wantTags: map[string]string{
+				"user_name":  "first second",
+				longKey[:32]: "value",
+				"long":       longValue[:200],
+				"component":  "system",
+			},
+		},
+		{
+			name:   "hash suffix",
+			policy: zapsentry.TagPolicyHashSuffix,
+			wantTags: map[string]string{
+				"user_name":                       "first second",
+				longKey[:23] + fnvSuffix(longKey): "value",
+				"long":                            longValue[:191] + fnvSuffix(longValue),
+				"component":                       "system",
+			},
+		},
+		{
+			name:   "context",
+			policy: zapsentry.TagPolicyContext,
+			wantTags: map[string]string{
+				"user_name": "first second",
+				"component": "system",
+			},
+			wantContext: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled []error
+			logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+				Level:        zapcore.ErrorLevel,
+				Tags:         map[string]string{"component": "system"},
+				TagPolicy:    tt.policy,
+				ErrorHandler: func(err error) { handled = append(handled, err) },
+			})
+
+			logger.Error("tagged",
+				zapsentry.Tag("user name", "first\nsecond"),
+				zapsentry.Tag(longKey, "value"),
+				zapsentry.Tag("long", longValue),
+			)
+
+			event := transport.LastEvent()
+			if len(event.Tags) != len(tt.wantTags) {
+				t.Errorf("expected tags %v, got %v", tt.wantTags, event.Tags)
+			}
+			for k, v := range tt.wantTags {
+				if event.Tags[k] != v {
+					t.Errorf("expected tag %s=%s, got %q", k, v, event.Tags[k])
+				}
+			}
+			if _, ok := event.Contexts["invalid_tags"]; ok != tt.wantContext {
+				t.Errorf("unexpected invalid_tags context %v", event.Contexts["invalid_tags"])
+			}
+			if len(handled) != 3 || !errors.Is(handled[0], zapsentry.ErrInvalidTag) {
+				t.Errorf("expected three invalid tags to be reported, got %v", handled)
+			}
+		})
+	}
+}
+
+func TestTagPolicyManyTags(t *testing.T) {
+	var handled []error
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:        zapcore.ErrorLevel,
+		TagPolicy:    zapsentry.TagPolicyHashSuffix,
+		ErrorHandler: func(err error) { handled = append(handled, err) },
+	})
+
+	// enough tags for the map iteration to visit keys added during it, if they were.
+	tags := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		tags[fmt.Sprintf("tag%d", i)] = "value"
+	}
+	longKey := strings.Repeat("k", 40)
+	tags[longKey] = "value"
+	tags["bad key"] = "value"
+
+	for i := 0; i < 20; i++ {
+		handled = nil
+		logger.Error("tagged", zapsentry.Tags(tags))
+
+		got := transport.LastEvent().Tags
+		if len(got) != 22 || got[longKey[:23]+fnvSuffix(longKey)] != "value" || got["bad_key"] != "value" {
+			t.Fatalf("unexpected tags %v", got)
+		}
+		if len(handled) != 2 {
+			t.Fatalf("expected 2 invalid tags, got %v", handled)
+		}
+	}
+}
+
+func TestTagPolicyCollisions(t *testing.T) {
+	var handled []error
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		Level:        zapcore.ErrorLevel,
+		ErrorHandler: func(err error) { handled = append(handled, err) },
+	})
+
+	for i := 0; i < 20; i++ {
+		handled = nil
+		logger.Error("tagged",
+			zapsentry.Tag("a b", "1"),
+			zapsentry.Tag("a/b", "2"),
+			zapsentry.Tag("c d", "3"),
+			zapsentry.Tag("c_d", "4"),
+		)
+
+		event := transport.LastEvent()
+		if want := map[string]string{"c_d": "4"}; !reflect.DeepEqual(event.Tags, want) {
+			t.Fatalf("expected tags %v, got %v", want, event.Tags)
+		}
+		want := map[string]interface{}{"a b": "1", "a/b": "2", "c d": "3"}
+		if got := map[string]interface{}(event.Contexts["invalid_tags"]); !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected invalid tags %v, got %v", want, got)
+		}
+		if len(handled) != 3 {
+			t.Fatalf("expected 3 invalid tags, got %v", handled)
+		}
+	}
+}
+
+type tenantKey struct{}
+
+func TestTagHelpers(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	logger = logger.With(
+		zapsentry.Tags(map[string]string{"region": "eu", "zone": "a"}),
+		zapsentry.TagInt("shard", 7),
+		zapsentry.TagFromContext("tenant", func(ctx context.Context) string {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return tenant
+		}),
+	)
+
+	logger.Error("without context", zapsentry.TagBool("retry", true), zapsentry.Tag("zone", "b"))
+	logger.Error("with context", zapsentry.Context(ctx), zapsentry.TagStringer("ip", net.IPv4(10, 0, 0, 1)))
+
+	want := map[string]string{"region": "eu", "zone": "b", "shard": "7", "retry": "true"}
+	if got := transport.FindEvent("without context").Tags; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected tags %v, got %v", want, got)
+	}
+
+	want = map[string]string{"region": "eu", "zone": "a", "shard": "7", "tenant": "acme", "ip": "10.0.0.1"}
+	if got := transport.FindEvent("with context").Tags; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected tags %v, got %v", want, got)
+	}
+}
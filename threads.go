@@ -0,0 +1,75 @@
+package zapsentry
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	initialThreadDumpSize = 64 << 10
+	maxThreadDumpSize     = 16 << 20
+)
+
+// threadDump returns the stacks of all goroutines, the current one first and marked as such,
+// as well as crashed if the entry crashes it.
+// Threads are named by the goroutine state and wait duration, e.g. "chan receive, 3 minutes".
+func (c *core) threadDump(crashed bool) []sentry.Thread {
+	dump := allGoroutineStacks()
+
+	var threads []sentry.Thread
+
+	for _, goroutine := range strings.Split(dump, "\n\n") {
+		header, stack, _ := strings.Cut(goroutine, "\n")
+
+		id, state, ok := parseGoroutineHeader(header)
+		if !ok {
+			continue
+		}
+
+		current := len(threads) == 0
+		thread := sentry.Thread{ID: id, Name: state, Current: current, Crashed: current && crashed}
+		if stacktrace := parseStacktrace(stack); stacktrace != nil {
+			stacktrace.Frames = c.filterFrames(stacktrace.Frames)
+			thread.Stacktrace = stacktrace
+		}
+
+		threads = append(threads, thread)
+	}
+
+	return threads
+}
+
+// allGoroutineStacks calls runtime.Stack with a buffer large enough for all goroutines,
+// up to maxThreadDumpSize, the last goroutines being cut off beyond it.
+func allGoroutineStacks() string {
+	buf := make([]byte, initialThreadDumpSize)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxThreadDumpSize {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parseGoroutineHeader parses lines like "goroutine 5 [chan receive, 3 minutes]:".
+func parseGoroutineHeader(header string) (id, state string, ok bool) {
+	rest, ok := strings.CutPrefix(header, "goroutine ")
+	if !ok {
+		return "", "", false
+	}
+
+	id, rest, ok = strings.Cut(rest, " ")
+	if !ok {
+		return "", "", false
+	}
+
+	start, end := strings.IndexByte(rest, '['), strings.LastIndex(rest, "]:")
+	if start < 0 || end < start {
+		return "", "", false
+	}
+
+	return id, rest[start+1 : end], true
+}
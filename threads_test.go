@@ -0,0 +1,102 @@
+package zapsentry_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func TestThreadDump(t *testing.T) {
+	// goroutines of the test package are skipped with the zapsentry module frames,
+	// so the blocked goroutine is recognized by the sync package ones.
+	var release sync.WaitGroup
+	release.Add(1)
+	defer release.Done()
+	started := make(chan struct{})
+	go func() {
+		close(started)
+		release.Wait()
+	}()
+	<-started
+
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	logger.Error("error")
+	if threads := transport.LastEvent().Threads; len(threads) != 0 {
+		t.Errorf("expected no threads for errors, got %d", len(threads))
+	}
+
+	logger.DPanic("crashing")
+
+	threads := transport.LastEvent().Threads
+	if len(threads) < 2 || !threads[0].Current || threads[0].Crashed {
+		t.Fatalf("expected the current goroutine first of several, not crashed by DPanic, got %+v", threads)
+	}
+
+	var blocked *sentry.Thread
+	for i, thread := range threads {
+		if i > 0 && (thread.Current || thread.Crashed) {
+			t.Errorf("expected a single current goroutine, got %+v", thread)
+		}
+		if thread.Stacktrace == nil {
+			continue
+		}
+		for _, frame := range thread.Stacktrace.Frames {
+			if strings.HasPrefix(frame.Module, "go.uber.org/zap") {
+				t.Errorf("expected zap frames to be skipped, got %+v", frame)
+			}
+			if frame.Module == "sync" && frame.Function == "(*WaitGroup).Wait" {
+				blocked = &threads[i]
+			}
+		}
+	}
+
+	if blocked == nil {
+		t.Fatalf("blocked goroutine not found in %+v", threads)
+	}
+	if !strings.HasPrefix(blocked.Name, "sync.WaitGroup.Wait") || blocked.ID == "" {
+		t.Errorf("unexpected blocked goroutine %q: %q", blocked.ID, blocked.Name)
+	}
+}
+
+func TestCrashedThread(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{Level: zapcore.ErrorLevel})
+
+	logPanic(logger, "crashing")
+
+	threads := transport.LastEvent().Threads
+	if len(threads) < 2 || !threads[0].Current || !threads[0].Crashed {
+		t.Fatalf("expected the current goroutine to be crashed, got %+v", threads)
+	}
+	for _, thread := range threads[1:] {
+		if thread.Crashed {
+			t.Errorf("expected a single crashed goroutine, got %+v", thread)
+		}
+	}
+}
+
+func TestDisableThreadDump(t *testing.T) {
+	transport := zapsentrytest.NewTransport()
+	client := zapsentrytest.NewClient(transport, sentry.ClientOptions{AttachStacktrace: true})
+	core, err := zapsentry.NewCore(zapsentry.Configuration{
+		Level:             zapcore.ErrorLevel,
+		DisableThreadDump: true,
+	}, zapsentry.NewSentryClientFromClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zap.New(core).DPanic("crashing")
+
+	threads := transport.LastEvent().Threads
+	if len(threads) != 1 || !threads[0].Current || threads[0].ID != "" || threads[0].Stacktrace == nil {
+		t.Fatalf("expected only the current stack, got %+v", threads)
+	}
+}
@@ -0,0 +1,86 @@
+package zapsentry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+type (
+	ExceptionTypeNamerFunc func(err error) string
+)
+
+// ExceptionTypeNamer names the type of sentry.Exception(s), which Sentry uses as the issue title.
+type ExceptionTypeNamer interface {
+	ExceptionTypeName(err error) string
+}
+
+// Sentinel is a named errors.Is target.
+type Sentinel struct {
+	Name string
+	Err  error
+}
+
+// DefaultExceptionTypeNamer is used when Configuration.ExceptionTypeNamer is not set.
+// An error is named, by priority:
+//   - by its TypeName() string method, if any;
+//   - by the first of Sentinels it matches with errors.Is;
+//   - by the type of the first error in its chain which is not a stdlib wrapper,
+//     such as the ones created by fmt.Errorf, errors.New or errors.Join;
+//   - by its own type.
+type DefaultExceptionTypeNamer struct {
+	Sentinels []Sentinel
+}
+
+var (
+	// stdlibWrapperTypes are the types carrying no information beyond the error message.
+	stdlibWrapperTypes = map[reflect.Type]struct{}{
+		reflect.TypeOf(errors.New("")):                                     {},
+		reflect.TypeOf(errors.Join(errors.New(""))):                        {},
+		reflect.TypeOf(fmt.Errorf("%w", errors.New(""))):                   {},
+		reflect.TypeOf(fmt.Errorf("%w%w", errors.New(""), errors.New(""))): {},
+	}
+)
+
+func (f ExceptionTypeNamerFunc) ExceptionTypeName(err error) string {
+	return f(err)
+}
+
+func (n DefaultExceptionTypeNamer) ExceptionTypeName(err error) string {
+	if cast, ok := err.(interface{ TypeName() string }); ok {
+		return cast.TypeName()
+	}
+
+	for _, sentinel := range n.Sentinels {
+		if errors.Is(err, sentinel.Err) {
+			return sentinel.Name
+		}
+	}
+
+	for cause := err; cause != nil; cause = unwrapFirst(cause) {
+		if _, ok := stdlibWrapperTypes[reflect.TypeOf(cause)]; ok {
+			continue
+		}
+		if cast, ok := cause.(interface{ TypeName() string }); ok {
+			return cast.TypeName()
+		}
+		return reflect.TypeOf(cause).String()
+	}
+
+	return reflect.TypeOf(err).String()
+}
+
+// unwrapFirst returns the cause of the error or the first of the joined errors.
+func unwrapFirst(err error) error {
+	switch cast := err.(type) {
+	case interface{ Unwrap() error }:
+		return cast.Unwrap()
+	case interface{ Cause() error }:
+		return cast.Cause()
+	case interface{ Unwrap() []error }:
+		if errs := cast.Unwrap(); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package zapsentry_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/TheZeroSlave/zapsentry"
+)
+
+var errNotFound = errors.New("not found")
+
+type namedError struct{}
+
+func (namedError) Error() string    { return "named" }
+func (namedError) TypeName() string { return "NamedError" }
+
+func TestDefaultExceptionTypeNamer(t *testing.T) {
+	t.Parallel()
+	namer := zapsentry.DefaultExceptionTypeNamer{
+		Sentinels: []zapsentry.Sentinel{{Name: "ErrNotFound", Err: errNotFound}},
+	}
+	pathErr := &os.PathError{Op: "open", Path: "/nonexistent", Err: fs.ErrNotExist}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "plain error", err: errors.New("boom"), want: "*errors.errorString"},
+		{name: "wrapped plain error", err: fmt.Errorf("wrapped: %w", errors.New("boom")), want: "*fmt.wrapError"},
+		{name: "sentinel", err: errNotFound, want: "ErrNotFound"},
+		{name: "wrapped sentinel", err: fmt.Errorf("get: %w", errNotFound), want: "ErrNotFound"},
+		{name: "wrapped typed error", err: fmt.Errorf("read config: %w", pathErr), want: "*fs.PathError"},
+		{name: "joined typed error", err: errors.Join(pathErr, errors.New("boom")), want: "*fs.PathError"},
+		{name: "type name method", err: fmt.Errorf("wrapped: %w", namedError{}), want: "NamedError"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := namer.ExceptionTypeName(tt.err); got != tt.want {
+				t.Errorf("ExceptionTypeName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package zapsentrytest
+
+import (
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+)
+
+// NewCore creates a zapsentry core recording its events to the returned transport.
+// The test fails immediately if the configuration is invalid.
+func NewCore(tb testing.TB, cfg zapsentry.Configuration) (zapcore.Core, *Transport) {
+	tb.Helper()
+
+	if cfg.Level == nil {
+		cfg.Level = zapcore.ErrorLevel
+	}
+
+	transport := NewTransport()
+	core, err := zapsentry.NewCore(cfg, zapsentry.NewSentryClientFromClient(NewClient(transport, sentry.ClientOptions{})))
+	if err != nil {
+		tb.Fatalf("zapsentry.NewCore: %v", err)
+	}
+
+	return core, transport
+}
+
+// NewLogger creates a logger writing only to a core made by NewCore.
+// The logger carries a fresh scope, so breadcrumbs do not leak between tests.
+func NewLogger(tb testing.TB, cfg zapsentry.Configuration) (*zap.Logger, *Transport) {
+	tb.Helper()
+
+	core, transport := NewCore(tb, cfg)
+
+	return zap.New(core).With(zapsentry.NewScope()), transport
+}
@@ -0,0 +1,35 @@
+package zapsentrytest
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// Event wraps a recorded sentry.Event with convenience accessors.
+// Accessors of a nil Event return zero values, so that lookups can be chained.
+type Event struct {
+	*sentry.Event
+}
+
+// Exceptions returns the exceptions of the event, the most recent error is the last one.
+func (e *Event) Exceptions() []sentry.Exception {
+	if e == nil || e.Event == nil {
+		return nil
+	}
+	return e.Exception
+}
+
+// Breadcrumbs returns the breadcrumbs attached to the event.
+func (e *Event) Breadcrumbs() []*sentry.Breadcrumb {
+	if e == nil || e.Event == nil {
+		return nil
+	}
+	return e.Event.Breadcrumbs
+}
+
+// Extra returns the zap fields of the event.
+func (e *Event) Extra() map[string]interface{} {
+	if e == nil || e.Event == nil {
+		return nil
+	}
+	return e.Contexts["Extra"]
+}
@@ -0,0 +1,98 @@
+package zapsentrytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set to a non-empty value,
+// makes AssertGolden rewrite the golden files instead of comparing against them.
+const UpdateGoldenEnv = "ZAPSENTRYTEST_UPDATE_GOLDEN"
+
+// volatileKeys are the event attributes differing from run to run or from machine to machine.
+var volatileKeys = []string{
+	"event_id", "timestamp", "sdk", "modules", "release", "server_name", "platform", "environment",
+}
+
+// volatileContexts are the contexts filled by sentry-go integrations and the core itself.
+var volatileContexts = []string{"device", "os", "runtime", "build", "trace"}
+
+// NormalizedJSON returns the indented JSON representation of the event without
+// attributes depending on the run, such as event IDs, timestamps and stack traces.
+func NormalizedJSON(e *Event) ([]byte, error) {
+	raw, err := json.Marshal(e.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, key := range volatileKeys {
+		delete(doc, key)
+	}
+	if contexts, ok := doc["contexts"].(map[string]interface{}); ok {
+		for _, key := range volatileContexts {
+			delete(contexts, key)
+		}
+	}
+	for _, key := range []string{"exception", "threads"} {
+		items, _ := doc[key].([]interface{})
+		for _, item := range items {
+			if item, ok := item.(map[string]interface{}); ok {
+				delete(item, "stacktrace")
+			}
+		}
+	}
+	breadcrumbs, _ := doc["breadcrumbs"].([]interface{})
+	for _, breadcrumb := range breadcrumbs {
+		if breadcrumb, ok := breadcrumb.(map[string]interface{}); ok {
+			delete(breadcrumb, "timestamp")
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// AssertGolden compares the normalized JSON of the event with the content of the golden file.
+// Run tests with ZAPSENTRYTEST_UPDATE_GOLDEN=1 to create or update golden files.
+func AssertGolden(tb testing.TB, e *Event, path string) {
+	tb.Helper()
+
+	if e == nil {
+		tb.Fatalf("no sentry event to compare with %s", path)
+	}
+
+	got, err := NormalizedJSON(e)
+	if err != nil {
+		tb.Fatalf("marshal sentry event: %v", err)
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("read golden file: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		tb.Errorf("sentry event does not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
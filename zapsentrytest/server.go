@@ -0,0 +1,270 @@
+package zapsentrytest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Server is a fake Sentry server speaking the envelope ingestion protocol.
+// It decodes received envelopes, so that tests can use the real sentry-go
+// HTTP transport without network access.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	events     []*sentry.Event
+	logs       []Log
+	checkIns   []CheckIn
+	requests   int
+	rateLimits string
+	errs       []error
+}
+
+// Log is a decoded Sentry log item.
+type Log struct {
+	Timestamp  time.Time               `json:"timestamp"`
+	TraceID    string                  `json:"trace_id"`
+	SpanID     string                  `json:"span_id"`
+	Level      sentry.LogLevel         `json:"level"`
+	Severity   int                     `json:"severity_number"`
+	Body       string                  `json:"body"`
+	Attributes map[string]LogAttribute `json:"attributes"`
+}
+
+// LogAttribute is a typed value of a Log attribute.
+type LogAttribute struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// CheckIn is a decoded Sentry cron monitor check-in.
+type CheckIn struct {
+	ID            string                `json:"check_in_id"`
+	MonitorSlug   string                `json:"monitor_slug"`
+	Status        sentry.CheckInStatus  `json:"status"`
+	Duration      float64               `json:"duration"`
+	Release       string                `json:"release"`
+	Environment   string                `json:"environment"`
+	MonitorConfig *sentry.MonitorConfig `json:"monitor_config"`
+}
+
+type envelopeItemHeader struct {
+	Type        string `json:"type"`
+	Length      *int   `json:"length"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+}
+
+// NewServer starts a fake Sentry server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// DSN returns the DSN pointing at the server.
+func (s *Server) DSN() string {
+	return fmt.Sprintf("http://public@%s/1", strings.TrimPrefix(s.URL, "http://"))
+}
+
+// SetRateLimits makes the server reject all subsequent envelopes with
+// "429 Too Many Requests" and the given X-Sentry-Rate-Limits header,
+// e.g. "60:error". Pass an empty string to accept envelopes again.
+func (s *Server) SetRateLimits(header string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimits = header
+}
+
+// Events returns the received error events with their attachments.
+func (s *Server) Events() []*Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]*Event, len(s.events))
+	for i, e := range s.events {
+		events[i] = &Event{e}
+	}
+	return events
+}
+
+// Logs returns the received log items.
+func (s *Server) Logs() []Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Log(nil), s.logs...)
+}
+
+// CheckIns returns the received check-ins.
+func (s *Server) CheckIns() []CheckIn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]CheckIn(nil), s.checkIns...)
+}
+
+// Requests returns the number of envelope requests, including the rejected ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// Err returns the errors of decoding malformed requests, if any.
+func (s *Server) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return errors.Join(s.errs...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/envelope/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if s.rateLimits != "" {
+		w.Header().Set("X-Sentry-Rate-Limits", s.rateLimits)
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	if err := s.decode(r); err != nil {
+		s.errs = append(s.errs, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (s *Server) decode(r *http.Request) error {
+	var body io.Reader = r.Body
+
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	case "deflate":
+		fl := flate.NewReader(r.Body)
+		defer fl.Close()
+		body = fl
+	default:
+		return fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	envelope := bufio.NewReader(body)
+
+	// The envelope header isn't used, but must be a valid JSON.
+	header, err := envelope.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if !json.Valid(bytes.TrimSpace(header)) {
+		return errors.New("invalid envelope header")
+	}
+
+	var event *sentry.Event
+	for {
+		line, err := envelope.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil
+			}
+			continue
+		}
+
+		var item envelopeItemHeader
+		if err := json.Unmarshal(line, &item); err != nil {
+			return fmt.Errorf("item header: %w", err)
+		}
+
+		payload, err := readPayload(envelope, item.Length)
+		if err != nil {
+			return fmt.Errorf("%s item: %w", item.Type, err)
+		}
+
+		switch item.Type {
+		case "event":
+			event = &sentry.Event{}
+			if err := json.Unmarshal(payload, event); err != nil {
+				return fmt.Errorf("event: %w", err)
+			}
+			s.events = append(s.events, event)
+		case "attachment":
+			if event == nil {
+				return errors.New("attachment without event")
+			}
+			event.Attachments = append(event.Attachments, &sentry.Attachment{
+				Filename:    item.Filename,
+				ContentType: item.ContentType,
+				Payload:     payload,
+			})
+		case "log":
+			var logs struct {
+				Items []Log `json:"items"`
+			}
+			if err := json.Unmarshal(payload, &logs); err != nil {
+				return fmt.Errorf("log: %w", err)
+			}
+			s.logs = append(s.logs, logs.Items...)
+		case "check_in":
+			var checkIn CheckIn
+			if err := json.Unmarshal(payload, &checkIn); err != nil {
+				return fmt.Errorf("check-in: %w", err)
+			}
+			s.checkIns = append(s.checkIns, checkIn)
+		}
+	}
+}
+
+// readPayload reads an item payload, which is either of the given length
+// or terminated by a newline.
+func readPayload(r *bufio.Reader, length *int) ([]byte, error) {
+	if length == nil {
+		payload, err := r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return bytes.TrimSuffix(payload, []byte("\n")), nil
+	}
+
+	payload := make([]byte, *length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if b, err := r.Peek(1); err == nil && b[0] == '\n' {
+		_, _ = r.Discard(1)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,110 @@
+package zapsentrytest_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+// gzipTransport compresses request bodies like a proxy in front of Sentry could do.
+type gzipTransport struct{}
+
+func (gzipTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	_, _ = gz.Write(body)
+	_ = gz.Close()
+
+	r = r.Clone(r.Context())
+	r.Body = io.NopCloser(&b)
+	r.ContentLength = int64(b.Len())
+	r.Header.Set("Content-Encoding", "gzip")
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newServerLogger(t *testing.T, opts sentry.ClientOptions) (*zap.Logger, *sentry.Client, *zapsentrytest.Server) {
+	server := zapsentrytest.NewServer()
+	t.Cleanup(server.Close)
+
+	opts.Dsn = server.DSN()
+	client, err := sentry.NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	core, err := zapsentry.NewCore(
+		zapsentry.Configuration{Level: zapcore.ErrorLevel},
+		zapsentry.NewSentryClientFromClient(client),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return zap.New(core), client, server
+}
+
+func TestServer(t *testing.T) {
+	logger, client, server := newServerLogger(t, sentry.ClientOptions{HTTPTransport: gzipTransport{}})
+
+	logger.Error("failed", zap.Error(errors.New("boom")), zap.String("key", "value"))
+	client.CaptureCheckIn(&sentry.CheckIn{MonitorSlug: "job", Status: sentry.CheckInStatusOK}, nil, nil)
+	if !client.Flush(time.Second) {
+		t.Fatal("flush timed out")
+	}
+
+	if err := server.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected exactly one event, got %d", len(events))
+	}
+	if events[0].Message != "failed" || events[0].Extra()["key"] != "value" {
+		t.Errorf("unexpected event %v", events[0].Event)
+	}
+	if exceptions := events[0].Exceptions(); len(exceptions) != 1 || exceptions[0].Value != "boom" {
+		t.Errorf("unexpected exceptions %v", exceptions)
+	}
+
+	checkIns := server.CheckIns()
+	if len(checkIns) != 1 || checkIns[0].MonitorSlug != "job" || checkIns[0].Status != sentry.CheckInStatusOK {
+		t.Errorf("unexpected check-ins %v", checkIns)
+	}
+}
+
+func TestServerRateLimits(t *testing.T) {
+	logger, client, server := newServerLogger(t, sentry.ClientOptions{})
+
+	server.SetRateLimits("60:error")
+	logger.Error("first")
+	client.Flush(time.Second)
+
+	logger.Error("second")
+	client.Flush(time.Second)
+
+	if requests := server.Requests(); requests != 1 {
+		t.Errorf("expected the client to respect rate limits, got %d requests", requests)
+	}
+	if events := server.Events(); len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+}
@@ -0,0 +1,143 @@
+// Package zapsentrytest provides helpers for testing code that reports to Sentry through zapsentry.
+package zapsentrytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Transport is a sentry.Transport keeping all the events in memory.
+// It is safe for concurrent use.
+type Transport struct {
+	mu        sync.Mutex
+	events    []*sentry.Event
+	failFlush bool
+	closed    bool
+}
+
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// NewClient creates a sentry.Client sending events to the given transport.
+// Zero options are fine; the Transport field is always overridden.
+func NewClient(transport *Transport, opts sentry.ClientOptions) *sentry.Client {
+	opts.Transport = transport
+	client, err := sentry.NewClient(opts)
+	if err != nil {
+		// the only possible error is an invalid DSN.
+		panic(err)
+	}
+	return client
+}
+
+// Flush waits until any buffered events are sent to the Sentry server, blocking
+// for at most the given timeout. It returns false if the timeout was reached.
+func (t *Transport) Flush(_ time.Duration) bool { return t.flushed() }
+
+// FlushWithContext waits until any buffered events are sent to the Sentry server,
+// blocking for at most the given context's deadline.
+func (t *Transport) FlushWithContext(_ context.Context) bool { return t.flushed() }
+
+// FailFlush makes subsequent flushes report a timeout if fail is set.
+func (t *Transport) FailFlush(fail bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failFlush = fail
+}
+
+func (t *Transport) flushed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return !t.failFlush
+}
+
+// Configure is called by the Client itself, providing it it's own ClientOptions.
+func (t *Transport) Configure(_ sentry.ClientOptions) {}
+
+// SendEvent records the event.
+func (t *Transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append(t.events, event)
+}
+
+// Close releases any resources held by the transport.
+func (t *Transport) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+}
+
+// Closed reports whether the transport was closed, e.g. by sentry.Client.Close.
+func (t *Transport) Closed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.closed
+}
+
+// Events returns all the recorded events in the order they were sent.
+func (t *Transport) Events() []*Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make([]*Event, len(t.events))
+	for i, e := range t.events {
+		events[i] = &Event{e}
+	}
+	return events
+}
+
+// Reset forgets all the recorded events.
+func (t *Transport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = nil
+}
+
+// LastEvent returns the most recent event or nil if there is none.
+func (t *Transport) LastEvent() *Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.events) == 0 {
+		return nil
+	}
+	return &Event{t.events[len(t.events)-1]}
+}
+
+// FindEvent returns the first event having the given message or nil if there is none.
+func (t *Transport) FindEvent(message string) *Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, e := range t.events {
+		if e.Message == message {
+			return &Event{e}
+		}
+	}
+	return nil
+}
+
+// AssertEventCount fails the test if the number of recorded events is not n.
+func (t *Transport) AssertEventCount(tb testing.TB, n int) {
+	tb.Helper()
+
+	if events := t.Events(); len(events) != n {
+		messages := make([]string, len(events))
+		for i, e := range events {
+			messages[i] = e.Message
+		}
+		tb.Errorf("expected %d sentry events, got %d: %q", n, len(events), messages)
+	}
+}
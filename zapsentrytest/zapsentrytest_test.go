@@ -0,0 +1,44 @@
+package zapsentrytest_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/TheZeroSlave/zapsentry"
+	"github.com/TheZeroSlave/zapsentry/zapsentrytest"
+)
+
+func TestLogger(t *testing.T) {
+	logger, transport := zapsentrytest.NewLogger(t, zapsentry.Configuration{
+		EnableBreadcrumbs: true,
+		BreadcrumbLevel:   zapcore.InfoLevel,
+		Tags:              map[string]string{"component": "system"},
+	})
+
+	logger.Info("starting", zap.Int("attempt", 1))
+	logger.Error("failed", zap.Error(errors.New("boom")), zapsentry.Tag("service", "app"))
+
+	transport.AssertEventCount(t, 1)
+	if transport.FindEvent("starting") != nil {
+		t.Error("expected no event for info message")
+	}
+
+	event := transport.FindEvent("failed")
+	if exceptions := event.Exceptions(); len(exceptions) != 1 || exceptions[0].Value != "boom" {
+		t.Errorf("unexpected exceptions %v", exceptions)
+	}
+	if breadcrumbs := event.Breadcrumbs(); len(breadcrumbs) != 2 {
+		t.Errorf("expected two breadcrumbs, got %d", len(breadcrumbs))
+	}
+
+	zapsentrytest.AssertGolden(t, event, "testdata/failed.golden.json")
+
+	transport.Reset()
+	transport.AssertEventCount(t, 0)
+	if transport.LastEvent().Exceptions() != nil {
+		t.Error("expected no exceptions without events")
+	}
+}